@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 	. "github.com/onsi/ginkgo"
@@ -95,7 +96,7 @@ jobs:
 func SetTestPipeline(pipelineName string, configFilePath string) error {
 	var err error
 	var setOutput []byte
-	setOutput, err = flyCommand.SetPipeline(pipelineName, configFilePath, nil, nil)
+	setOutput, err = flyCommand.SetPipeline(concourse.PipelineRef{Name: pipelineName}, configFilePath, nil, nil)
 	fmt.Fprintf(GinkgoWriter, "pipeline '%s' set; output:\n\n%s\n", pipelineName, string(setOutput))
 	return err
 }
@@ -177,10 +178,18 @@ var _ = BeforeSuite(func() {
 
 	By("Creating fly connection")
 	l := logger.NewLogger(sanitizer)
-	flyCommand = fly.NewCommand("concourse-pipeline-resource-target", l, inFlyPath)
+	flyCommand = fly.NewCommand("concourse-pipeline-resource-target", "", l, inFlyPath, nil)
 
 	By("Logging in with fly")
-	_, err = flyCommand.Login(target, teamName, username, password, insecure)
+	_, err = flyCommand.Login(
+		target,
+		concourse.Team{
+			Name:     teamName,
+			Username: username,
+			Password: password,
+		},
+		concourse.TLSConfig{Insecure: insecure},
+	)
 	Expect(err).NotTo(HaveOccurred())
 })
 
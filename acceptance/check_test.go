@@ -86,7 +86,7 @@ var _ = Describe("Check", func() {
 
 			AfterEach(func() {
 				if testPipelineCreated {
-					_, err := flyCommand.DestroyPipeline(testPipelineName)
+					_, err := flyCommand.DestroyPipeline(concourse.PipelineRef{Name: testPipelineName})
 					Expect(err).NotTo(HaveOccurred())
 				}
 			})
@@ -95,7 +95,7 @@ var _ = Describe("In", func() {
 
 			AfterEach(func() {
 				if testPipelineCreated {
-					_, err := flyCommand.DestroyPipeline(testPipelineName)
+					_, err := flyCommand.DestroyPipeline(concourse.PipelineRef{Name: testPipelineName})
 					Expect(err).NotTo(HaveOccurred())
 				}
 			})
@@ -155,7 +155,7 @@ jobs:
 
 	Describe("Creating pipelines successfully", func() {
 		AfterEach(func() {
-			_, err := flyCommand.DestroyPipeline(pipelineName)
+			_, err := flyCommand.DestroyPipeline(concourse.PipelineRef{Name: pipelineName})
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -170,7 +170,7 @@ jobs:
 			Expect(err).ShouldNot(HaveOccurred())
 
 			By("Validating output contains pipeline version")
-			Expect(response.Version[pipelineName]).NotTo(BeEmpty())
+			Expect(response.Version[teamName+"/"+pipelineName]).NotTo(BeEmpty())
 		})
 
 		Context("when pipelines_file is provided instead", func() {
@@ -190,7 +190,7 @@ jobs:
 				Expect(err).ShouldNot(HaveOccurred())
 
 				By("Validating output contains pipeline version")
-				Expect(response.Version[pipelineName]).NotTo(BeEmpty())
+				Expect(response.Version[teamName+"/"+pipelineName]).NotTo(BeEmpty())
 			})
 		})
 
@@ -217,7 +217,7 @@ jobs:
 				Expect(err).ShouldNot(HaveOccurred())
 
 				By("Validating output contains pipeline version")
-				Expect(response.Version[pipelineName]).NotTo(BeEmpty())
+				Expect(response.Version[teamName+"/"+pipelineName]).NotTo(BeEmpty())
 			})
 		})
 	})
@@ -1,32 +1,40 @@
 package check
 
 import (
-	"crypto/md5"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
 )
 
 type Command struct {
 	logger      logger.Logger
 	logFilePath string
-	flyCommand  fly.Command
+	flyCommands fly.CommandFactory
+}
+
+type pipelineGet struct {
+	teamName   string
+	pipeline   concourse.PipelineRef
+	flyCommand fly.Command
 }
 
 func NewCommand(
 	logger logger.Logger,
 	logFilePath string,
-	flyCommand fly.Command,
+	flyCommands fly.CommandFactory,
 ) *Command {
 	return &Command{
 		logger:      logger,
 		logFilePath: logFilePath,
-		flyCommand:  flyCommand,
+		flyCommands: flyCommands,
 	}
 }
 
@@ -53,64 +61,146 @@ func (c *Command) Run(input concourse.CheckRequest) (concourse.CheckResponse, er
 
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
+	pipelineVersions := make(map[string]string)
+
+	for _, target := range input.Source.TargetSources() {
+		targetVersions, err := c.checkTarget(target.Name, target.Source)
 		if err != nil {
+			if target.Name != "" {
+				return concourse.CheckResponse{}, fmt.Errorf("target '%s': %v", target.Name, err)
+			}
 			return concourse.CheckResponse{}, err
 		}
+
+		for key, version := range targetVersions {
+			pipelineVersions[concourse.TargetVersionKey(target.Name, key)] = version
+		}
 	}
 
-	teams := make(map[string]concourse.Team)
+	out := concourse.CheckResponse{
+		pipelineVersions,
+	}
+
+	c.logger.Debugf("Returning output: %+v\n", out)
+
+	return out, nil
+}
 
-	for _, team := range input.Source.Teams {
-		teams[team.Name] = team
+// checkTarget returns the versions of the pipelines of a single target.
+func (c *Command) checkTarget(targetName string, source concourse.Source) (map[string]string, error) {
+	tlsConfig, err := source.TLSConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	pipelineVersions := make(map[string]string)
+	legacyVersionKeys := false
+	if source.LegacyVersionKeys != "" {
+		var err error
+		legacyVersionKeys, err = strconv.ParseBool(source.LegacyVersionKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	teams := source.Teams
 
-	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
-			insecure,
+	if source.AllTeams != "" {
+		allTeams, err := strconv.ParseBool(source.AllTeams)
+		if err != nil {
+			return nil, err
+		}
+
+		if allTeams {
+			c.logger.Debugf("Discovering teams\n")
+			teams, err = fly.DiscoverTeams(c.flyCommands(targetName, concourse.MainTeamName), source, tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+			c.logger.Debugf("Discovered teams: %+v\n", teams)
+		}
+	}
+
+	flyCommands := make([]fly.Command, len(teams))
+	teamPipelines := make([][]concourse.PipelineRef, len(teams))
+
+	err = parallel.Run(len(teams), source.Concurrency, func(i int) error {
+		team := teams[i]
+		flyCommand := c.flyCommands(targetName, team.Name)
+
+		c.logger.Debugf("Performing login (%s)\n", team.Name)
+		_, err := flyCommand.Login(
+			source.Target,
+			team,
+			tlsConfig,
 		)
 		if err != nil {
-			return concourse.CheckResponse{}, err
+			return err
 		}
 
-		c.logger.Debugf("Login successful\n")
+		c.logger.Debugf("Login successful (%s)\n", team.Name)
 
-		pipelines, err := c.flyCommand.Pipelines()
+		pipelines, err := flyCommand.Pipelines()
 		if err != nil {
-			return concourse.CheckResponse{}, err
+			return err
 		}
-		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
+		c.logger.Debugf("Found pipelines (%s): %+v\n", team.Name, pipelines)
 
-		for _, pipelineName := range pipelines {
-			c.logger.Debugf("Getting pipeline: %s\n", pipelineName)
-			outBytes, err := c.flyCommand.GetPipeline(pipelineName)
-			if err != nil {
-				return concourse.CheckResponse{}, err
+		pipelineFilter, err := filter.New(team.IncludePipelines, team.ExcludePipelines)
+		if err != nil {
+			return err
+		}
+
+		for _, pipeline := range pipelines {
+			if !pipelineFilter.Match(pipeline.Name) {
+				c.logger.Debugf("Skipping filtered pipeline (%s): %s\n", team.Name, pipeline)
+				continue
 			}
 
-			version := fmt.Sprintf(
-				"%x",
-				md5.Sum(outBytes),
-			)
-			pipelineVersions[pipelineName] = version
+			teamPipelines[i] = append(teamPipelines[i], pipeline)
 		}
+
+		flyCommands[i] = flyCommand
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	out := concourse.CheckResponse{
-		pipelineVersions,
+	var gets []pipelineGet
+	for i, team := range teams {
+		for _, pipeline := range teamPipelines[i] {
+			gets = append(gets, pipelineGet{
+				teamName:   team.Name,
+				pipeline:   pipeline,
+				flyCommand: flyCommands[i],
+			})
+		}
 	}
 
-	c.logger.Debugf("Returning output: %+v\n", out)
+	versions := make([]string, len(gets))
 
-	return out, nil
+	err = parallel.Run(len(gets), source.Concurrency, func(i int) error {
+		get := gets[i]
+
+		c.logger.Debugf("Getting pipeline (%s): %s\n", get.teamName, get.pipeline)
+		outBytes, err := get.flyCommand.GetPipeline(get.pipeline)
+		if err != nil {
+			return err
+		}
+
+		versions[i], err = pipelineconfig.Version(outBytes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineVersions := make(map[string]string)
+	for i, get := range gets {
+		key := concourse.PipelineVersionKey(get.teamName, get.pipeline.String(), legacyVersionKeys)
+		pipelineVersions[key] = versions[i]
+	}
+
+	return pipelineVersions, nil
 }
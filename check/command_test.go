@@ -1,14 +1,16 @@
 package check_test
 
 import (
-	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/concourse/concourse-pipeline-resource/check"
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 	. "github.com/onsi/ginkgo"
@@ -34,10 +36,13 @@ var _ = Describe("Check", func() {
 		pipelinesErr   error
 		pipelines      []string
 		fakeFlyCommand *flyfakes.FakeCommand
+
+		teamFakeFlyCommands map[string]*flyfakes.FakeCommand
 	)
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		teamFakeFlyCommands = map[string]*flyfakes.FakeCommand{}
 
 		pipelinesErr = nil
 		pipelines = []string{"pipeline 1", "pipeline 2"}
@@ -52,10 +57,10 @@ pipeline1: foo
 pipeline2: foo
 `
 
-		fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
-			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", name)
+		fakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", pipeline)
 
-			switch name {
+			switch pipeline.Name {
 			case pipelines[0]:
 				return []byte(pipelineContents[0]), nil
 			case pipelines[1]:
@@ -97,15 +102,20 @@ pipeline2: foo
 
 		expectedResponse = []concourse.Version{
 			{
-				pipelines[0]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
-				pipelines[1]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+				"main/" + pipelines[0]: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"pipeline1":"foo"}`))),
+				"main/" + pipelines[1]: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"pipeline2":"foo"}`))),
 			},
 		}
 
 		command = check.NewCommand(
 			ginkgoLogger,
 			logFilePath,
-			fakeFlyCommand,
+			func(targetName string, teamName string) fly.Command {
+				if teamFakeFlyCommand, ok := teamFakeFlyCommands[path.Join(targetName, teamName)]; ok {
+					return teamFakeFlyCommand
+				}
+				return fakeFlyCommand
+			},
 		)
 	})
 
@@ -115,7 +125,7 @@ pipeline2: foo
 	})
 
 	JustBeforeEach(func() {
-		fakeFlyCommand.PipelinesReturns(pipelines, pipelinesErr)
+		fakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), pipelinesErr)
 	})
 
 	It("returns pipelines checksum without error", func() {
@@ -128,8 +138,8 @@ pipeline2: foo
 	Context("when the most recent version is provided", func() {
 		BeforeEach(func() {
 			checkRequest.Version = concourse.Version{
-				pipelines[0]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
-				pipelines[1]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+				"main/" + pipelines[0]: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"pipeline1":"foo"}`))),
+				"main/" + pipelines[1]: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"pipeline2":"foo"}`))),
 			}
 		})
 
@@ -153,6 +163,316 @@ pipeline2: foo
 		})
 	})
 
+	Context("when pipeline filters are provided", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Teams[0].ExcludePipelines = []string{"* 2"}
+		})
+
+		It("only gets and returns versions for the matching pipelines", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.GetPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{Name: pipelines[0]}))
+
+			Expect(response).To(Equal(concourse.CheckResponse{
+				{
+					"main/" + pipelines[0]: expectedResponse[0]["main/"+pipelines[0]],
+				},
+			}))
+		})
+	})
+
+	Context("when multiple teams have pipelines with the same name", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Teams = append(checkRequest.Source.Teams, concourse.Team{
+				Name:     "other-team",
+				Username: "other user",
+				Password: "other password",
+			})
+
+			for _, teamName := range []string{"main", "other-team"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				teamName := teamName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[teamName] = teamFakeFlyCommand
+			}
+		})
+
+		versionFor := func(teamName string, pipelineName string) string {
+			canonical := fmt.Sprintf(`{"pipeline":%q,"team":%q}`, pipelineName, teamName)
+			return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(canonical)))
+		}
+
+		It("returns versions qualified by team", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{
+				{
+					"main/" + pipelines[0]:       versionFor("main", pipelines[0]),
+					"main/" + pipelines[1]:       versionFor("main", pipelines[1]),
+					"other-team/" + pipelines[0]: versionFor("other-team", pipelines[0]),
+					"other-team/" + pipelines[1]: versionFor("other-team", pipelines[1]),
+				},
+			}))
+		})
+
+		It("logs in to each team with its own fly command", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			for teamName, teamFakeFlyCommand := range teamFakeFlyCommands {
+				Expect(teamFakeFlyCommand.LoginCallCount()).To(Equal(1))
+				_, team, _ := teamFakeFlyCommand.LoginArgsForCall(0)
+				Expect(team.Name).To(Equal(teamName))
+			}
+		})
+
+		Context("when concurrency is provided", func() {
+			BeforeEach(func() {
+				checkRequest.Source.Concurrency = 4
+			})
+
+			It("returns the same versions", func() {
+				response, err := command.Run(checkRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response[0]).To(HaveLen(4))
+				Expect(response[0]["other-team/"+pipelines[1]]).To(Equal(versionFor("other-team", pipelines[1])))
+			})
+		})
+
+		Context("when legacy version keys are requested", func() {
+			BeforeEach(func() {
+				checkRequest.Source.LegacyVersionKeys = "true"
+			})
+
+			It("returns versions keyed by pipeline name, with later teams taking precedence", func() {
+				response, err := command.Run(checkRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response).To(Equal(concourse.CheckResponse{
+					{
+						pipelines[0]: versionFor("other-team", pipelines[0]),
+						pipelines[1]: versionFor("other-team", pipelines[1]),
+					},
+				}))
+			})
+		})
+	})
+
+	Context("when all teams are requested", func() {
+		BeforeEach(func() {
+			checkRequest.Source.AllTeams = "true"
+			checkRequest.Source.ExcludeTeams = []string{"excluded-*"}
+			checkRequest.Source.Teams = append(checkRequest.Source.Teams, concourse.Team{
+				Name:             "team-b",
+				Token:            "team-b token",
+				IncludePipelines: []string{pipelines[0]},
+			})
+
+			for _, teamName := range []string{"main", "team-a", "team-b"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				teamName := teamName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[teamName] = teamFakeFlyCommand
+			}
+
+			teamFakeFlyCommands["main"].TeamsReturns([]concourse.TeamConfig{
+				{Name: "main"},
+				{Name: "team-a"},
+				{Name: "team-b"},
+				{Name: "excluded-team"},
+			}, nil)
+		})
+
+		It("returns the versions of every team visible to the main team", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(HaveLen(1))
+			Expect(response[0]).To(HaveLen(5))
+			Expect(response[0]).To(HaveKey("main/" + pipelines[1]))
+			Expect(response[0]).To(HaveKey("team-a/" + pipelines[1]))
+			Expect(response[0]).To(HaveKey("team-b/" + pipelines[0]))
+			Expect(response[0]).NotTo(HaveKey("team-b/" + pipelines[1]))
+		})
+
+		It("logs in to unlisted teams with the main team's credentials", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(teamFakeFlyCommands["main"].LoginCallCount()).To(Equal(2))
+
+			_, team, _ := teamFakeFlyCommands["team-a"].LoginArgsForCall(0)
+			Expect(team.Name).To(Equal("team-a"))
+			Expect(team.Username).To(Equal("some user"))
+			Expect(team.Password).To(Equal("some password"))
+
+			_, team, _ = teamFakeFlyCommands["team-b"].LoginArgsForCall(0)
+			Expect(team.Token).To(Equal("team-b token"))
+		})
+
+		Context("when listing teams returns an error", func() {
+			BeforeEach(func() {
+				teamFakeFlyCommands["main"].TeamsReturns(nil, fmt.Errorf("teams failed"))
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(checkRequest)
+				Expect(err).To(MatchError("teams failed"))
+			})
+		})
+	})
+
+	Context("when all teams fails to parse into a boolean", func() {
+		BeforeEach(func() {
+			checkRequest.Source.AllTeams = "not a bool"
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when multiple targets are provided", func() {
+		BeforeEach(func() {
+			checkRequest.Source = concourse.Source{
+				CACert: "some-ca-cert",
+				Targets: []concourse.Target{
+					{
+						Name:   "prod",
+						Target: "prod target",
+						Teams:  checkRequest.Source.Teams,
+					},
+					{
+						Name:     "dr",
+						Target:   "dr target",
+						Insecure: "true",
+						CACert:   "some-dr-ca-cert",
+						Teams:    checkRequest.Source.Teams,
+					},
+				},
+			}
+
+			for _, targetName := range []string{"prod", "dr"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				targetName := targetName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("target: %s\npipeline: %s\n", targetName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[targetName+"/main"] = teamFakeFlyCommand
+			}
+		})
+
+		versionFor := func(targetName string, pipelineName string) string {
+			canonical := fmt.Sprintf(`{"pipeline":%q,"target":%q}`, pipelineName, targetName)
+			return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(canonical)))
+		}
+
+		It("returns versions namespaced by target", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{
+				{
+					"prod/main/" + pipelines[0]: versionFor("prod", pipelines[0]),
+					"prod/main/" + pipelines[1]: versionFor("prod", pipelines[1]),
+					"dr/main/" + pipelines[0]:   versionFor("dr", pipelines[0]),
+					"dr/main/" + pipelines[1]:   versionFor("dr", pipelines[1]),
+				},
+			}))
+		})
+
+		It("logs in to each target with its own settings", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			url, _, tlsConfig := teamFakeFlyCommands["prod/main"].LoginArgsForCall(0)
+			Expect(url).To(Equal("prod target"))
+			Expect(tlsConfig.Insecure).To(BeFalse())
+			Expect(tlsConfig.CACert).To(Equal("some-ca-cert"))
+
+			url, _, tlsConfig = teamFakeFlyCommands["dr/main"].LoginArgsForCall(0)
+			Expect(url).To(Equal("dr target"))
+			Expect(tlsConfig.Insecure).To(BeTrue())
+			Expect(tlsConfig.CACert).To(Equal("some-dr-ca-cert"))
+		})
+
+		Context("when a target returns an error", func() {
+			BeforeEach(func() {
+				teamFakeFlyCommands["dr/main"].PipelinesReturns(nil, fmt.Errorf("pipelines failed"))
+			})
+
+			It("returns an error naming the target", func() {
+				_, err := command.Run(checkRequest)
+				Expect(err).To(MatchError("target 'dr': pipelines failed"))
+			})
+		})
+	})
+
+	Context("when a pipeline has several instances", func() {
+		var (
+			instances []concourse.PipelineRef
+		)
+
+		BeforeEach(func() {
+			instances = []concourse.PipelineRef{
+				{Name: pipelines[0], InstanceVars: map[string]interface{}{"env": "dev"}},
+				{Name: pipelines[0], InstanceVars: map[string]interface{}{"env": "prod"}},
+			}
+
+			instancedFakeFlyCommand := &flyfakes.FakeCommand{}
+			instancedFakeFlyCommand.PipelinesReturns(instances, nil)
+			instancedFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+				return []byte(fmt.Sprintf("env: %s\n", pipeline.InstanceVars["env"])), nil
+			}
+
+			teamFakeFlyCommands["main"] = instancedFakeFlyCommand
+		})
+
+		It("gets and returns a version for each instance", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(teamFakeFlyCommands["main"].GetPipelineCallCount()).To(Equal(2))
+			Expect(teamFakeFlyCommands["main"].GetPipelineArgsForCall(1)).To(Equal(instances[1]))
+
+			Expect(response).To(Equal(concourse.CheckResponse{
+				{
+					"main/" + pipelines[0] + "/env:dev":  fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"env":"dev"}`))),
+					"main/" + pipelines[0] + "/env:prod": fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(`{"env":"prod"}`))),
+				},
+			}))
+		})
+	})
+
+	Context("when legacy version keys fails to parse into a boolean", func() {
+		BeforeEach(func() {
+			checkRequest.Source.LegacyVersionKeys = "unparsable"
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("when log files already exist", func() {
 		var (
 			otherFilePath1 string
@@ -196,9 +516,31 @@ pipeline2: foo
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
-			_, _, _, _, insecure := fakeFlyCommand.LoginArgsForCall(0)
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
+
+			Expect(tlsConfig.Insecure).To(BeTrue())
+		})
+	})
+
+	Context("when a CA certificate and client certificate are provided", func() {
+		BeforeEach(func() {
+			checkRequest.Source.CACert = "some-ca-cert"
+			checkRequest.Source.ClientCert = "some-client-cert"
+			checkRequest.Source.ClientKey = "some-client-key"
+		})
+
+		It("invokes the login with them", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
 
-			Expect(insecure).To(BeTrue())
+			Expect(tlsConfig).To(Equal(concourse.TLSConfig{
+				CACert:     "some-ca-cert",
+				ClientCert: "some-client-cert",
+				ClientKey:  "some-client-key",
+			}))
 		})
 	})
 
@@ -244,6 +586,17 @@ pipeline2: foo
 		})
 	})
 
+	Context("when the pipeline config cannot be parsed", func() {
+		BeforeEach(func() {
+			pipelineContents[0] = "{{{"
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("when calling fly to get pipeline config returns an error", func() {
 		var (
 			expectedErr error
@@ -263,3 +616,11 @@ pipeline2: foo
 		})
 	})
 })
+
+func pipelineRefs(names ...string) []concourse.PipelineRef {
+	refs := make([]concourse.PipelineRef, len(names))
+	for i, name := range names {
+		refs[i] = concourse.PipelineRef{Name: name}
+	}
+	return refs
+}
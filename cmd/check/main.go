@@ -18,6 +18,7 @@ import (
 
 const (
 	flyBinaryName        = "fly"
+	flyCacheDirName      = "concourse-pipeline-resource-fly-cache"
 	atcExternalURLEnvKey = "ATC_EXTERNAL_URL"
 )
 
@@ -53,20 +54,36 @@ func main() {
 
 	flyBinaryPath := filepath.Join(checkDir, flyBinaryName)
 
-	if input.Source.Target == "" {
+	if input.Source.Target == "" && len(input.Source.Targets) == 0 {
 		input.Source.Target = os.Getenv(atcExternalURLEnvKey)
 	}
 
-	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath)
-
 	err = validator.ValidateCheck(input)
 	if err != nil {
 		l.Debugf("Exiting with error: %v\n", err)
 		log.Fatalln(err)
 	}
 
-	command := check.NewCommand(l, logFile.Name(), flyCommand)
+	targets, err := fly.NewTargets(
+		input.Source.Client,
+		input.Source.Target,
+		l,
+		flyBinaryPath,
+		filepath.Join(os.TempDir(), flyCacheDirName),
+	)
+	if err != nil {
+		l.Debugf("Exiting with error: %v\n", err)
+		log.Fatalln(err)
+	}
+
+	command := check.NewCommand(l, logFile.Name(), targets.Command)
 	response, err := command.Run(input)
+
+	cleanupErr := targets.Cleanup()
+	if cleanupErr != nil {
+		l.Debugf("Failed to remove fly targets: %v\n", cleanupErr)
+	}
+
 	if err != nil {
 		l.Debugf("Exiting with error: %v\n", err)
 		log.Fatalln(err)
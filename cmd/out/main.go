@@ -19,6 +19,7 @@ import (
 
 const (
 	flyBinaryName        = "fly"
+	flyCacheDirName      = "concourse-pipeline-resource-fly-cache"
 	atcExternalURLEnvKey = "ATC_EXTERNAL_URL"
 )
 
@@ -61,12 +62,10 @@ func main() {
 
 	flyBinaryPath := filepath.Join(outDir, flyBinaryName)
 
-	if input.Source.Target == "" {
+	if input.Source.Target == "" && len(input.Source.Targets) == 0 {
 		input.Source.Target = os.Getenv(atcExternalURLEnvKey)
 	}
 
-	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath)
-
 	err = validator.ValidateOut(input)
 	if err != nil {
 		l.Debugf("Exiting with error: %v\n", err)
@@ -91,7 +90,25 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	response, err := out.NewCommand(l, flyCommand, sourcesDir).Run(input)
+	targets, err := fly.NewTargets(
+		input.Source.Client,
+		input.Source.Target,
+		l,
+		flyBinaryPath,
+		filepath.Join(os.TempDir(), flyCacheDirName),
+	)
+	if err != nil {
+		l.Debugf("Exiting with error: %v\n", err)
+		log.Fatalln(err)
+	}
+
+	response, err := out.NewCommand(l, targets.Command, sourcesDir).Run(input)
+
+	cleanupErr := targets.Cleanup()
+	if cleanupErr != nil {
+		l.Debugf("Failed to remove fly targets: %v\n", cleanupErr)
+	}
+
 	if err != nil {
 		l.Debugf("Exiting with error: %v\n", err)
 		log.Fatalln(err)
@@ -0,0 +1,101 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MaxPipelineMetadata is how many pipelines are listed in metadata, so that
+// the metadata of many pipelines remains readable.
+const MaxPipelineMetadata = 20
+
+const (
+	PipelineStatusCreated   = "created"
+	PipelineStatusUpdated   = "updated"
+	PipelineStatusUnchanged = "unchanged"
+	PipelineStatusArchived  = "archived"
+)
+
+// PipelineMetadata is what is reported about a single pipeline which was got
+// or put.
+type PipelineMetadata struct {
+	TargetName string
+	Target     string
+	TeamName   string
+	Ref        PipelineRef
+	Version    string
+
+	// Status is how the pipeline was put, and is empty for pipelines which
+	// were got.
+	Status string
+}
+
+// PipelinesMetadata returns an entry per pipeline, keyed by team and
+// pipeline, listing at most MaxPipelineMetadata pipelines followed by a count
+// of the others.
+func PipelinesMetadata(pipelines []PipelineMetadata) []Metadata {
+	var metadata []Metadata
+
+	for i, p := range pipelines {
+		if i == MaxPipelineMetadata {
+			metadata = append(metadata, Metadata{
+				Name:  "more",
+				Value: fmt.Sprintf("%d more pipelines not listed", len(pipelines)-i),
+			})
+			break
+		}
+
+		var fields []string
+		if p.Status != "" {
+			fields = append(fields, fmt.Sprintf("status: %s", p.Status))
+		}
+		fields = append(fields,
+			fmt.Sprintf("paused: %t", p.Ref.Paused),
+			fmt.Sprintf("config: %s", p.Version),
+			fmt.Sprintf("url: %s", PipelineURL(p.Target, p.TeamName, p.Ref)),
+		)
+
+		metadata = append(metadata, Metadata{
+			Name:  TargetVersionKey(p.TargetName, fmt.Sprintf("%s/%s", p.TeamName, p.Ref)),
+			Value: strings.Join(fields, ", "),
+		})
+	}
+
+	return metadata
+}
+
+// PipelineURL returns the URL of the pipeline in the web UI of the target,
+// e.g. `https://ci.example.com/teams/main/pipelines/my-pipeline`, with any
+// instance vars in the query.
+func PipelineURL(target string, teamName string, ref PipelineRef) string {
+	pipelineURL := fmt.Sprintf(
+		"%s/teams/%s/pipelines/%s",
+		strings.TrimRight(target, "/"),
+		url.PathEscape(teamName),
+		url.PathEscape(ref.Name),
+	)
+
+	if len(ref.InstanceVars) == 0 {
+		return pipelineURL
+	}
+
+	keys := make([]string, 0, len(ref.InstanceVars))
+	for k := range ref.InstanceVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	query := make([]string, len(keys))
+	for i, k := range keys {
+		value, err := json.Marshal(ref.InstanceVars[k])
+		if err != nil {
+			value = []byte(fmt.Sprint(ref.InstanceVars[k]))
+		}
+		query[i] = fmt.Sprintf("vars.%s=%s", url.QueryEscape(k), url.QueryEscape(string(value)))
+	}
+
+	return fmt.Sprintf("%s?%s", pipelineURL, strings.Join(query, "&"))
+}
@@ -0,0 +1,54 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// PipelineOrder is how the pipelines of each team are to be ordered.
+// It is either true, to order each team's pipelines as they are declared, or
+// a map of team name to the names of its pipelines in order.
+type PipelineOrder struct {
+	Declared bool
+	Teams    map[string][]string
+}
+
+func (o PipelineOrder) MarshalJSON() ([]byte, error) {
+	if o.Teams != nil {
+		return json.Marshal(o.Teams)
+	}
+
+	return json.Marshal(o.Declared)
+}
+
+func (o *PipelineOrder) UnmarshalJSON(b []byte) error {
+	*o = PipelineOrder{}
+
+	if json.Unmarshal(b, &o.Declared) == nil {
+		return nil
+	}
+
+	if json.Unmarshal(b, &o.Teams) == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s must be either a boolean or a map of team name to pipeline names", "order")
+}
+
+// IsZero returns true if no ordering is requested.
+func (o PipelineOrder) IsZero() bool {
+	return !o.Declared && len(o.Teams) == 0
+}
+
+// TeamNames returns the sorted names of the teams whose pipelines are
+// explicitly ordered.
+func (o PipelineOrder) TeamNames() []string {
+	var teamNames []string
+	for teamName := range o.Teams {
+		teamNames = append(teamNames, teamName)
+	}
+	sort.Strings(teamNames)
+
+	return teamNames
+}
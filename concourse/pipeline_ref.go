@@ -0,0 +1,80 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PipelineRef identifies a pipeline, or one instance of an instanced pipeline.
+// Paused, Public and Archived are only known for pipelines as listed by
+// Concourse.
+type PipelineRef struct {
+	Name         string                 `json:"name"`
+	InstanceVars map[string]interface{} `json:"instance_vars,omitempty"`
+	Paused       bool                   `json:"paused,omitempty"`
+	Public       bool                   `json:"public,omitempty"`
+	Archived     bool                   `json:"archived,omitempty"`
+}
+
+var plainInstanceVar = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+// String formats the ref as fly does, e.g. `my-pipeline/env:prod,region:eu`.
+func (r PipelineRef) String() string {
+	if len(r.InstanceVars) == 0 {
+		return r.Name
+	}
+
+	keys := make([]string, 0, len(r.InstanceVars))
+	for k := range r.InstanceVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%s", k, formatInstanceVar(r.InstanceVars[k]))
+	}
+
+	return fmt.Sprintf("%s/%s", r.Name, strings.Join(parts, ","))
+}
+
+// formatInstanceVar renders the value as JSON, leaving strings unquoted where
+// they cannot be mistaken for any other value.
+func formatInstanceVar(value interface{}) string {
+	if s, ok := value.(string); ok && plainInstanceVar.MatchString(s) {
+		switch s {
+		case "true", "false", "null":
+		default:
+			return s
+		}
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(payload)
+}
+
+// PipelineFileName returns the name of the file in which something about the
+// pipeline is written, e.g. `team-1-my-pipeline.yml`. Any instance vars are
+// included, with slashes replaced so that the name remains a single file.
+func PipelineFileName(teamName string, ref PipelineRef, extension string) string {
+	return fmt.Sprintf("%s-%s.%s", teamName, fileSafeName(ref), extension)
+}
+
+// TeamPipelineFileName returns the path of the file in which something about
+// the pipeline is written within a directory per team, e.g.
+// `team-1/my-pipeline.yml`, which cannot collide across teams.
+func TeamPipelineFileName(teamName string, ref PipelineRef, extension string) string {
+	return filepath.Join(teamName, fmt.Sprintf("%s.%s", fileSafeName(ref), extension))
+}
+
+func fileSafeName(ref PipelineRef) string {
+	return strings.Replace(ref.String(), "/", "_", -1)
+}
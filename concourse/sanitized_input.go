@@ -5,9 +5,28 @@ import "fmt"
 func SanitizedSource(source Source) map[string]string {
 	s := make(map[string]string)
 
-	for i, t := range source.Teams {
-		if t.Password != "" {
-			s[t.Password] = fmt.Sprintf("***REDACTED-PASSWORD-TEAM-%d***", i)
+	for _, target := range source.TargetSources() {
+		prefix := ""
+		if target.Name != "" {
+			prefix = fmt.Sprintf("TARGET-%s-", target.Name)
+		}
+
+		if target.Source.ClientKey != "" {
+			s[target.Source.ClientKey] = fmt.Sprintf("***REDACTED-%sCLIENT-KEY***", prefix)
+		}
+
+		for i, t := range target.Source.Teams {
+			if t.Password != "" {
+				s[t.Password] = fmt.Sprintf("***REDACTED-PASSWORD-%sTEAM-%d***", prefix, i)
+			}
+
+			if t.Token != "" {
+				s[t.Token] = fmt.Sprintf("***REDACTED-TOKEN-%sTEAM-%d***", prefix, i)
+			}
+
+			if t.ClientSecret != "" {
+				s[t.ClientSecret] = fmt.Sprintf("***REDACTED-CLIENT-SECRET-%sTEAM-%d***", prefix, i)
+			}
 		}
 	}
 
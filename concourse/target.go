@@ -0,0 +1,97 @@
+package concourse
+
+import "fmt"
+
+// Target is one of several Concourse installs to run against, with its own
+// teams and TLS settings.
+type Target struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	Insecure   string `json:"insecure"`
+	CACert     string `json:"ca_cert"`
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+	Teams      []Team `json:"teams"`
+}
+
+// TargetSource is the source for a single target, named unless it is the only
+// target.
+type TargetSource struct {
+	Name   string
+	Source Source
+}
+
+// TargetSources returns a source per entry of targets, each otherwise
+// inheriting the settings of this source, or this source, unnamed, if no
+// targets are provided.
+func (s Source) TargetSources() []TargetSource {
+	if len(s.Targets) == 0 {
+		return []TargetSource{{Source: s}}
+	}
+
+	sources := make([]TargetSource, len(s.Targets))
+	for i, t := range s.Targets {
+		source := s
+		source.Targets = nil
+		source.Target = t.Target
+		source.Teams = t.Teams
+		if t.Insecure != "" {
+			source.Insecure = t.Insecure
+		}
+		if t.CACert != "" {
+			source.CACert = t.CACert
+		}
+		if t.ClientCert != "" || t.ClientKey != "" {
+			source.ClientCert = t.ClientCert
+			source.ClientKey = t.ClientKey
+		}
+
+		sources[i] = TargetSource{Name: t.Name, Source: source}
+	}
+
+	return sources
+}
+
+// TargetVersionKey qualifies a version key by target, unless the target is
+// unnamed.
+func TargetVersionKey(targetName string, key string) string {
+	if targetName == "" {
+		return key
+	}
+
+	return fmt.Sprintf("%s/%s", targetName, key)
+}
+
+// OnTarget returns true if the pipeline is to be set on the named target.
+// Pipelines without targets are set on every target.
+func (p Pipeline) OnTarget(targetName string) bool {
+	if len(p.Targets) == 0 || targetName == "" {
+		return true
+	}
+
+	for _, t := range p.Targets {
+		if t == targetName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForTarget returns the request for the given target, with only the pipelines
+// which are to be set on it.
+func (r OutRequest) ForTarget(target TargetSource) OutRequest {
+	targetRequest := r
+	targetRequest.Source = target.Source
+
+	if r.Params.Pipelines != nil {
+		targetRequest.Params.Pipelines = []Pipeline{}
+		for _, p := range r.Params.Pipelines {
+			if p.OnTarget(target.Name) {
+				targetRequest.Params.Pipelines = append(targetRequest.Params.Pipelines, p)
+			}
+		}
+	}
+
+	return targetRequest
+}
@@ -0,0 +1,31 @@
+package concourse
+
+import "strconv"
+
+// TLSConfig is how to verify, and authenticate to, a Concourse target.
+// Certificates and keys are PEM encoded.
+type TLSConfig struct {
+	Insecure   bool
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+// TLSConfig returns the TLS settings of the source.
+func (s Source) TLSConfig() (TLSConfig, error) {
+	insecure := false
+	if s.Insecure != "" {
+		var err error
+		insecure, err = strconv.ParseBool(s.Insecure)
+		if err != nil {
+			return TLSConfig{}, err
+		}
+	}
+
+	return TLSConfig{
+		Insecure:   insecure,
+		CACert:     s.CACert,
+		ClientCert: s.ClientCert,
+		ClientKey:  s.ClientKey,
+	}, nil
+}
@@ -1,15 +1,71 @@
 package concourse
 
+const (
+	ClientFly = "fly"
+	ClientAPI = "api"
+)
+
+const MainTeamName = "main"
+
+const (
+	TeamRoleOwner            = "owner"
+	TeamRoleMember           = "member"
+	TeamRolePipelineOperator = "pipeline-operator"
+	TeamRoleViewer           = "viewer"
+)
+
+const (
+	PipelineStatePaused   = "paused"
+	PipelineStateUnpaused = "unpaused"
+	PipelineStateArchived = "archived"
+
+	PipelineVisibilityExposed = "exposed"
+	PipelineVisibilityHidden  = "hidden"
+)
+
+const (
+	VersionMismatchWarn = "warn"
+	VersionMismatchFail = "fail"
+
+	LayoutFlat = "flat"
+	LayoutTeam = "team"
+
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
 type Source struct {
 	Target   string `json:"target"`
 	Teams    []Team `json:"teams"`
 	Insecure string `json:"insecure"`
+	Client   string `json:"client"`
+
+	CACert     string `json:"ca_cert"`
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	Targets []Target `json:"targets"`
+
+	Concurrency int `json:"concurrency"`
+
+	LegacyVersionKeys string `json:"legacy_version_keys"`
+
+	AllTeams     string   `json:"all_teams"`
+	IncludeTeams []string `json:"include_teams"`
+	ExcludeTeams []string `json:"exclude_teams"`
 }
 
 type Team struct {
-	Name     string `json:"name"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Name         string `json:"name"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Token        string `json:"token"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	TokenURL     string `json:"token_url"`
+
+	IncludePipelines []string `json:"include_pipelines"`
+	ExcludePipelines []string `json:"exclude_pipelines"`
 }
 
 type CheckRequest struct {
@@ -28,6 +84,40 @@ type InRequest struct {
 }
 
 type InParams struct {
+	OnVersionMismatch string `json:"on_version_mismatch,omitempty"`
+
+	Layout string `json:"layout,omitempty"`
+	Format string `json:"format,omitempty"`
+	Index  bool   `json:"index,omitempty"`
+
+	PipelinesFile string `json:"pipelines_file,omitempty"`
+	ConfigDir     string `json:"config_dir,omitempty"`
+}
+
+// FileExtension returns the extension of the files the configs are written
+// to, given their format.
+func (p InParams) FileExtension() string {
+	if p.Format == FormatJSON {
+		return "json"
+	}
+
+	return "yml"
+}
+
+// PipelineFileName returns the path, relative to the download directory, of
+// the file the config of the pipeline is written to, given the layout.
+func (p InParams) PipelineFileName(teamName string, ref PipelineRef) string {
+	if p.Layout == LayoutTeam {
+		return TeamPipelineFileName(teamName, ref, p.FileExtension())
+	}
+
+	return PipelineFileName(teamName, ref, p.FileExtension())
+}
+
+// FailsOnVersionMismatch returns true if configs which do not match the
+// requested version are to fail the get, rather than only be warned about.
+func (p InParams) FailsOnVersionMismatch() bool {
+	return p.OnVersionMismatch == VersionMismatchFail
 }
 
 type InResponse struct {
@@ -48,16 +138,84 @@ type OutRequest struct {
 type OutParams struct {
 	Pipelines     []Pipeline `json:"pipelines,omitempty"`
 	PipelinesFile string     `json:"pipelines_file,omitempty"`
+
+	DryRun  bool   `json:"dry_run,omitempty"`
+	DiffDir string `json:"diff_dir,omitempty"`
+
+	StrictValidation bool `json:"strict_validation,omitempty"`
+
+	Atomic bool `json:"atomic,omitempty"`
+
+	FailFast   *bool  `json:"fail_fast,omitempty"`
+	ReportFile string `json:"report_file,omitempty"`
+
+	Prune              bool     `json:"prune,omitempty"`
+	PruneDryRun        bool     `json:"prune_dry_run,omitempty"`
+	ProtectedPipelines []string `json:"protected_pipelines,omitempty"`
+
+	Order PipelineOrder `json:"order" yaml:"-"`
+
+	Teams                       []TeamConfig `json:"teams,omitempty"`
+	TeamsDryRun                 bool         `json:"teams_dry_run,omitempty"`
+	AllowDestructiveTeamChanges bool         `json:"allow_destructive_team_changes,omitempty"`
+}
+
+// FailsFast returns true unless fail_fast is false, in which case every
+// pipeline is to be attempted whatever the others' errors.
+func (p OutParams) FailsFast() bool {
+	return p.FailFast == nil || *p.FailFast
+}
+
+// TeamConfig is the auth config of a team, keyed by role name.
+type TeamConfig struct {
+	Name  string              `json:"name" yaml:"name"`
+	Roles map[string]TeamRole `json:"roles" yaml:"roles"`
+}
+
+// TeamRole is who is granted a role, as users and groups of the form
+// <connector>:<name>, e.g. local:admin or github:my-org:my-team.
+type TeamRole struct {
+	Users  []string `json:"users,omitempty" yaml:"users,omitempty"`
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
 }
 
 type Pipeline struct {
-	Name       string                 `json:"name" yaml:"name"`
-	ConfigFile string                 `json:"config_file" yaml:"config_file"`
-	VarsFiles  []string               `json:"vars_files" yaml:"vars_files"`
-	Vars       map[string]interface{} `json:"vars" yaml:"vars"`
-	TeamName   string                 `json:"team" yaml:"team"`
-	Unpaused   bool                   `json:"unpaused" yaml:"unpaused"`
-	Exposed    bool                   `json:"exposed" yaml:"exposed"`
+	Name         string                 `json:"name" yaml:"name"`
+	InstanceVars map[string]interface{} `json:"instance_vars,omitempty" yaml:"instance_vars,omitempty"`
+	ConfigFile   string                 `json:"config_file" yaml:"config_file"`
+	VarsFiles    []string               `json:"vars_files" yaml:"vars_files"`
+	Vars         map[string]interface{} `json:"vars" yaml:"vars"`
+	TeamName     string                 `json:"team" yaml:"team"`
+	Unpaused     bool                   `json:"unpaused" yaml:"unpaused"`
+	Exposed      bool                   `json:"exposed" yaml:"exposed"`
+	State        string                 `json:"state,omitempty" yaml:"state,omitempty"`
+	Visibility   string                 `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+	Targets      []string               `json:"targets,omitempty" yaml:"targets,omitempty"`
+}
+
+// Ref returns the ref of the pipeline, or pipeline instance, to be set.
+func (p Pipeline) Ref() PipelineRef {
+	return PipelineRef{Name: p.Name, InstanceVars: p.InstanceVars}
+}
+
+// DesiredState returns the state the pipeline is to be brought to, falling
+// back to unpaused, or empty (i.e. left as is), for unpaused.
+func (p Pipeline) DesiredState() string {
+	if p.State == "" && p.Unpaused {
+		return PipelineStateUnpaused
+	}
+
+	return p.State
+}
+
+// DesiredVisibility returns the visibility the pipeline is to be brought to,
+// falling back to exposed, or empty (i.e. left as is), for exposed.
+func (p Pipeline) DesiredVisibility() string {
+	if p.Visibility == "" && p.Exposed {
+		return PipelineVisibilityExposed
+	}
+
+	return p.Visibility
 }
 
 type OutResponse struct {
@@ -0,0 +1,14 @@
+package concourse
+
+import "fmt"
+
+// PipelineVersionKey returns the key under which the version of a pipeline is
+// recorded. Keys are qualified by team so that pipelines with the same name in
+// different teams do not collide, unless legacy keys are requested.
+func PipelineVersionKey(teamName string, pipelineName string, legacy bool) string {
+	if legacy {
+		return pipelineName
+	}
+
+	return fmt.Sprintf("%s/%s", teamName, pipelineName)
+}
@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Filter matches names against lists of include and exclude patterns.
+//
+// Patterns are globs (e.g. `deploy-*`) unless they are wrapped in slashes
+// (e.g. `/^deploy-(dev|prod)$/`), in which case they are regular expressions.
+type Filter struct {
+	include []matcher
+	exclude []matcher
+}
+
+type matcher func(name string) bool
+
+func New(include []string, exclude []string) (*Filter, error) {
+	includeMatchers, err := matchers(include)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeMatchers, err := matchers(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Filter{
+		include: includeMatchers,
+		exclude: excludeMatchers,
+	}, nil
+}
+
+// Match returns true if the name matches any include pattern (or there are no
+// include patterns) and does not match any exclude pattern.
+func (f *Filter) Match(name string) bool {
+	if len(f.include) > 0 && !anyMatch(f.include, name) {
+		return false
+	}
+
+	return !anyMatch(f.exclude, name)
+}
+
+func anyMatch(ms []matcher, name string) bool {
+	for _, m := range ms {
+		if m(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchers(patterns []string) ([]matcher, error) {
+	ms := make([]matcher, len(patterns))
+
+	for i, p := range patterns {
+		if len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression '%s': %v", p, err)
+			}
+
+			ms[i] = re.MatchString
+			continue
+		}
+
+		// Check the glob is well-formed up front, as path.Match only reports
+		// this when it reaches the malformed part of the pattern.
+		_, err := path.Match(p, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+
+		glob := p
+		ms[i] = func(name string) bool {
+			matched, _ := path.Match(glob, name)
+			return matched
+		}
+	}
+
+	return ms, nil
+}
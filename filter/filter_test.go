@@ -0,0 +1,88 @@
+package filter_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/filter"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	var (
+		include []string
+		exclude []string
+
+		f *filter.Filter
+	)
+
+	BeforeEach(func() {
+		include = nil
+		exclude = nil
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		f, err = filter.New(include, exclude)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when no patterns are provided", func() {
+		It("matches everything", func() {
+			Expect(f.Match("anything")).To(BeTrue())
+		})
+	})
+
+	Context("when include globs are provided", func() {
+		BeforeEach(func() {
+			include = []string{"deploy-*", "build"}
+		})
+
+		It("matches only the included names", func() {
+			Expect(f.Match("deploy-prod")).To(BeTrue())
+			Expect(f.Match("build")).To(BeTrue())
+			Expect(f.Match("build-2")).To(BeFalse())
+			Expect(f.Match("test")).To(BeFalse())
+		})
+
+		Context("when exclude globs are also provided", func() {
+			BeforeEach(func() {
+				exclude = []string{"*-prod"}
+			})
+
+			It("gives exclusions precedence", func() {
+				Expect(f.Match("deploy-dev")).To(BeTrue())
+				Expect(f.Match("deploy-prod")).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when regular expressions are provided", func() {
+		BeforeEach(func() {
+			include = []string{"/^deploy-(dev|prod)$/"}
+			exclude = []string{"/prod/"}
+		})
+
+		It("matches using the regular expressions", func() {
+			Expect(f.Match("deploy-dev")).To(BeTrue())
+			Expect(f.Match("deploy-prod")).To(BeFalse())
+			Expect(f.Match("deploy-staging")).To(BeFalse())
+		})
+	})
+
+	Context("when a regular expression is invalid", func() {
+		It("returns an error", func() {
+			_, err := filter.New([]string{"/(/"}, nil)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*invalid regular expression.*"))
+		})
+	})
+
+	Context("when a glob is invalid", func() {
+		It("returns an error", func() {
+			_, err := filter.New(nil, []string{"["})
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*invalid glob.*"))
+		})
+	})
+})
@@ -0,0 +1,484 @@
+package fly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	configVersionHeader = "X-Concourse-Config-Version"
+
+	// These are the public OAuth client credentials that fly itself uses to
+	// exchange a username and password for a token.
+	flyClientID     = "fly"
+	flyClientSecret = "Zmx5"
+)
+
+type apiCommand struct {
+	logger        logger.Logger
+	httpClient    *http.Client
+	flyBinaryPath string
+
+	url      string
+	teamName string
+	token    string
+}
+
+// NewAPICommand returns a Command which talks directly to the Concourse HTTP
+// API rather than shelling out to a fly binary, other than the fly at
+// flyBinaryPath to validate pipeline configs.
+func NewAPICommand(logger logger.Logger, flyBinaryPath string) Command {
+	return &apiCommand{
+		logger:        logger,
+		httpClient:    &http.Client{},
+		flyBinaryPath: flyBinaryPath,
+	}
+}
+
+func (a *apiCommand) Login(
+	atcURL string,
+	team concourse.Team,
+	tlsConfig concourse.TLSConfig,
+) ([]byte, error) {
+	// Until this login succeeds, no requests may be made as the previous team
+	a.url = ""
+	a.teamName = ""
+	a.token = ""
+
+	atcURL = strings.TrimRight(atcURL, "/")
+	httpClient, err := newHTTPClient(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := teamToken(httpClient, team)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil && team.Username != "" && team.Password != "" {
+		a.logger.Debugf("Requesting token for team: %s\n", team.Name)
+		t, err = requestToken(
+			httpClient,
+			atcURL+"/sky/issuer/token",
+			passwordGrant(team),
+			flyClientID,
+			flyClientSecret,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.url = atcURL
+	a.teamName = team.Name
+	a.httpClient = httpClient
+
+	if t == nil {
+		return []byte(fmt.Sprintf("targeting team '%s' without authentication\n", team.Name)), nil
+	}
+
+	a.token = t.header()
+
+	return []byte(fmt.Sprintf("logged in to team '%s'\n", team.Name)), nil
+}
+
+func (a *apiCommand) Pipelines() ([]concourse.PipelineRef, error) {
+	body, err := a.request(http.MethodGet, a.teamPath("pipelines"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ps []concourse.PipelineRef
+
+	err = json.Unmarshal(body, &ps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave out archived pipelines, as `fly pipelines` does
+	var unarchived []concourse.PipelineRef
+	for _, p := range ps {
+		if !p.Archived {
+			unarchived = append(unarchived, p)
+		}
+	}
+
+	return unarchived, nil
+}
+
+func (a *apiCommand) GetPipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	config, _, err := a.getConfig(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		return nil, fmt.Errorf("pipeline '%s' not found", pipeline)
+	}
+
+	// Re-encode as YAML so that the output matches that of `fly get-pipeline`
+	var ordered yaml.MapSlice
+	err = yaml.Unmarshal(config, &ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(ordered)
+}
+
+func (a *apiCommand) SetPipeline(
+	pipeline concourse.PipelineRef,
+	configFilepath string,
+	varsFilepaths []string,
+	vars map[string]interface{},
+) ([]byte, error) {
+	config, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, version, err := a.getConfig(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := a.pipelinePath(pipeline, "config")
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/x-yaml",
+	}
+	if version != "" {
+		headers[configVersionHeader] = version
+	}
+
+	body, err := a.request(
+		http.MethodPut,
+		path,
+		bytes.NewReader(config),
+		headers,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Warnings []struct {
+			Message string `json:"message"`
+		} `json:"warnings"`
+	}
+
+	// The response body is only used for warnings, so tolerate it being empty
+	_ = json.Unmarshal(body, &response)
+
+	output := bytes.NewBuffer(nil)
+	for _, w := range response.Warnings {
+		fmt.Fprintf(output, "WARNING: %s\n", w.Message)
+	}
+
+	if existing == nil {
+		fmt.Fprintf(output, "pipeline created!\n")
+	} else {
+		fmt.Fprintf(output, "configuration updated\n")
+	}
+
+	return output.Bytes(), nil
+}
+
+// ValidatePipeline runs `fly validate-pipeline` with the bundled fly, as the
+// API has no way to validate a config without setting it.
+func (a *apiCommand) ValidatePipeline(configFilepath string, strict bool) ([]byte, error) {
+	args := []string{
+		"validate-pipeline",
+		"-c", configFilepath,
+	}
+
+	if strict {
+		args = append(args, "--strict")
+	}
+
+	return runFly(a.logger, a.flyBinaryPath, "", args...)
+}
+
+func (a *apiCommand) DestroyPipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodDelete, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("`%s` deleted\n", pipeline)), nil
+}
+
+func (a *apiCommand) UnpausePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodPut, pipeline, "unpause")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("unpaused '%s'\n", pipeline)), nil
+}
+
+func (a *apiCommand) ExposePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodPut, pipeline, "expose")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("exposed '%s'\n", pipeline)), nil
+}
+
+func (a *apiCommand) PausePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodPut, pipeline, "pause")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("paused '%s'\n", pipeline)), nil
+}
+
+func (a *apiCommand) HidePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodPut, pipeline, "hide")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("hid '%s'\n", pipeline)), nil
+}
+
+func (a *apiCommand) ArchivePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	err := a.pipelineRequest(http.MethodPut, pipeline, "archive")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("archived '%s'\n", pipeline)), nil
+}
+
+func (a *apiCommand) OrderPipelines(pipelineNames []string) ([]byte, error) {
+	body, err := json.Marshal(pipelineNames)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.request(
+		http.MethodPut,
+		a.teamPath("pipelines", "ordering"),
+		bytes.NewReader(body),
+		map[string]string{"Content-Type": "application/json"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("ordered pipelines %v\n", pipelineNames)), nil
+}
+
+func (a *apiCommand) Teams() ([]concourse.TeamConfig, error) {
+	body, err := a.request(http.MethodGet, apiPath("teams"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return teamConfigs(body)
+}
+
+func (a *apiCommand) SetTeam(team concourse.TeamConfig) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{"auth": team.Roles})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.request(
+		http.MethodPut,
+		apiPath("teams", team.Name),
+		bytes.NewReader(body),
+		map[string]string{"Content-Type": "application/json"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("team '%s' configured\n", team.Name)), nil
+}
+
+// getConfig returns the raw JSON config of the pipeline along with its config
+// version. A nil config is returned if the pipeline does not exist.
+func (a *apiCommand) getConfig(pipeline concourse.PipelineRef) ([]byte, string, error) {
+	path, err := a.pipelinePath(pipeline, "config")
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := a.newRequest(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", unexpectedResponse(req, resp, body)
+	}
+
+	var config struct {
+		Config json.RawMessage `json:"config"`
+	}
+
+	err = json.Unmarshal(body, &config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return config.Config, resp.Header.Get(configVersionHeader), nil
+}
+
+// pipelineRequest makes a request without a body to the pipeline's endpoint.
+func (a *apiCommand) pipelineRequest(
+	method string,
+	pipeline concourse.PipelineRef,
+	segments ...string,
+) error {
+	path, err := a.pipelinePath(pipeline, segments...)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.request(method, path, nil, nil)
+	return err
+}
+
+func (a *apiCommand) request(
+	method string,
+	path string,
+	body io.Reader,
+	headers map[string]string,
+) ([]byte, error) {
+	req, err := a.newRequest(method, path, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.do(req)
+}
+
+func (a *apiCommand) newRequest(
+	method string,
+	path string,
+	body io.Reader,
+	headers map[string]string,
+) (*http.Request, error) {
+	if a.url == "" {
+		return nil, fmt.Errorf("login must be performed before %s %s", method, path)
+	}
+
+	req, err := http.NewRequest(method, a.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.token != "" {
+		req.Header.Set("Authorization", a.token)
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return req, nil
+}
+
+func (a *apiCommand) do(req *http.Request) ([]byte, error) {
+	a.logger.Debugf("Starting API request: %s %s\n", req.Method, req.URL.Path)
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return body, unexpectedResponse(req, resp, body)
+	}
+
+	return body, nil
+}
+
+func (a *apiCommand) teamPath(segments ...string) string {
+	return apiPath(append([]string{"teams", a.teamName}, segments...)...)
+}
+
+// pipelinePath returns the path of the pipeline's endpoint, with the instance
+// vars, if any, in the query string as Concourse expects.
+func (a *apiCommand) pipelinePath(pipeline concourse.PipelineRef, segments ...string) (string, error) {
+	path := a.teamPath(append([]string{"pipelines", pipeline.Name}, segments...)...)
+
+	if len(pipeline.InstanceVars) == 0 {
+		return path, nil
+	}
+
+	instanceVars, err := json.Marshal(pipeline.InstanceVars)
+	if err != nil {
+		return "", err
+	}
+
+	return path + "?" + url.Values{"vars": {string(instanceVars)}}.Encode(), nil
+}
+
+func passwordGrant(team concourse.Team) url.Values {
+	return url.Values{
+		"grant_type": {"password"},
+		"username":   {team.Username},
+		"password":   {team.Password},
+		"scope":      {"openid profile email federated:id groups"},
+	}
+}
+
+func apiPath(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = url.PathEscape(s)
+	}
+
+	return apiPrefix + "/" + strings.Join(escaped, "/")
+}
+
+func unexpectedResponse(req *http.Request, resp *http.Response, body []byte) error {
+	err := fmt.Errorf("unexpected response from %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	if len(body) > 0 {
+		err = fmt.Errorf("%v - %s", err, strings.TrimSpace(string(body)))
+	}
+	return err
+}
@@ -0,0 +1,766 @@
+package fly_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/logger/loggerfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeATC struct {
+	sync.Mutex
+
+	server *httptest.Server
+
+	token     string
+	pipelines map[string]map[string]string
+	versions  map[string]string
+	archived  map[string]bool
+	auth      map[string]string
+
+	requests []*http.Request
+	bodies   []string
+}
+
+func newFakeATC() *fakeATC {
+	atc := &fakeATC{
+		token: "some-token",
+		pipelines: map[string]map[string]string{
+			"main": {
+				"pipeline-1": `{"jobs":[{"name":"job-1"}],"resources":[]}`,
+				"pipeline-2": `{"jobs":[]}`,
+			},
+		},
+		versions: map[string]string{
+			"pipeline-1": "7",
+		},
+		archived: map[string]bool{},
+		auth: map[string]string{
+			"main": `{"owner":{"users":["local:admin"],"groups":[]}}`,
+		},
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/sky/issuer/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost ||
+			clientID != "fly" ||
+			clientSecret != "Zmx5" ||
+			r.FormValue("grant_type") != "password" ||
+			r.FormValue("username") != "some-username" ||
+			r.FormValue("password") != "some-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"token_type":   "Bearer",
+			"access_token": atc.token,
+		})
+	})
+
+	mux.HandleFunc(apiPrefix+"/teams", func(w http.ResponseWriter, r *http.Request) {
+		atc.Lock()
+		defer atc.Unlock()
+
+		atc.requests = append(atc.requests, r)
+		atc.bodies = append(atc.bodies, "")
+
+		if r.Header.Get("Authorization") != "Bearer "+atc.token || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var ts []map[string]interface{}
+		for _, name := range sortedKeys(atc.auth) {
+			ts = append(ts, map[string]interface{}{"name": name, "auth": json.RawMessage(atc.auth[name])})
+		}
+		json.NewEncoder(w).Encode(ts)
+	})
+
+	mux.HandleFunc(apiPrefix+"/teams/", func(w http.ResponseWriter, r *http.Request) {
+		atc.Lock()
+		defer atc.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		atc.requests = append(atc.requests, r)
+		atc.bodies = append(atc.bodies, string(body))
+
+		if r.Header.Get("Authorization") != "Bearer "+atc.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var team, pipeline, action string
+		segments := strings.Split(strings.TrimPrefix(r.URL.Path, apiPrefix+"/teams/"), "/")
+		team = segments[0]
+		if len(segments) > 2 {
+			pipeline = segments[2]
+		}
+		if len(segments) > 3 {
+			action = segments[3]
+		}
+
+		if len(segments) == 1 && r.Method == http.MethodPut {
+			var t struct {
+				Auth json.RawMessage `json:"auth"`
+			}
+			json.Unmarshal(body, &t)
+			atc.auth[team] = string(t.Auth)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		pipelines, found := atc.pipelines[team]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch {
+		case pipeline == "ordering" && r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+
+		case pipeline == "" && r.Method == http.MethodGet:
+			var ps []map[string]interface{}
+			for _, name := range sortedKeys(pipelines) {
+				ps = append(ps, map[string]interface{}{"name": name, "archived": atc.archived[name]})
+			}
+			json.NewEncoder(w).Encode(ps)
+
+		case action == "config" && r.Method == http.MethodGet:
+			config, found := pipelines[pipeline]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Concourse-Config-Version", atc.versions[pipeline])
+			w.Write([]byte(`{"config":` + config + `}`))
+
+		case action == "config" && r.Method == http.MethodPut:
+			_, exists := pipelines[pipeline]
+			if exists && r.Header.Get("X-Concourse-Config-Version") != atc.versions[pipeline] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			pipelines[pipeline] = string(body)
+			if exists {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusCreated)
+			}
+			w.Write([]byte(`{"warnings":[{"type":"deprecation","message":"some warning"}]}`))
+
+		case action == "" && r.Method == http.MethodDelete:
+			delete(pipelines, pipeline)
+			w.WriteHeader(http.StatusNoContent)
+
+		case stringIn(action, "unpause", "expose", "pause", "hide", "archive") && r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusOK)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	atc.server = httptest.NewServer(mux)
+
+	return atc
+}
+
+func (atc *fakeATC) lastRequest() (*http.Request, string) {
+	atc.Lock()
+	defer atc.Unlock()
+
+	return atc.requests[len(atc.requests)-1], atc.bodies[len(atc.bodies)-1]
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var _ = Describe("APICommand", func() {
+	var (
+		atc *fakeATC
+
+		apiCommand fly.Command
+
+		team concourse.Team
+
+		fakeLogger *loggerfakes.FakeLogger
+	)
+
+	BeforeEach(func() {
+		atc = newFakeATC()
+
+		team = concourse.Team{
+			Name:     "main",
+			Username: "some-username",
+			Password: "some-password",
+		}
+
+		fakeLogger = &loggerfakes.FakeLogger{}
+
+		apiCommand = fly.NewAPICommand(fakeLogger, "")
+	})
+
+	AfterEach(func() {
+		atc.server.Close()
+	})
+
+	Describe("Login", func() {
+		It("exchanges the username and password for a token", func() {
+			_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = apiCommand.Pipelines()
+			Expect(err).NotTo(HaveOccurred())
+
+			req, _ := atc.lastRequest()
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+		})
+
+		Context("when the credentials are invalid", func() {
+			BeforeEach(func() {
+				team.Password = "wrong-password"
+			})
+
+			It("returns an error", func() {
+				_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*401.*"))
+			})
+
+			Context("after a successful login to another team", func() {
+				It("does not make requests as the other team", func() {
+					otherTeam := concourse.Team{Name: "other-team"}
+					_, err := apiCommand.Login(atc.server.URL, otherTeam, concourse.TLSConfig{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+					Expect(err).To(HaveOccurred())
+
+					_, err = apiCommand.Pipelines()
+					Expect(err).To(MatchError(ContainSubstring("login must be performed")))
+				})
+			})
+		})
+
+		Context("when a token is provided", func() {
+			BeforeEach(func() {
+				team = concourse.Team{
+					Name:  "main",
+					Token: "some-token",
+				}
+			})
+
+			It("uses the token without logging in", func() {
+				_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = apiCommand.Pipelines()
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+			})
+		})
+
+		Context("when the ATC requires a client certificate", func() {
+			var (
+				tlsServer  *httptest.Server
+				tlsConfig  concourse.TLSConfig
+				clientCert string
+				clientKey  string
+			)
+
+			BeforeEach(func() {
+				clientCert, clientKey = selfSignedCert()
+
+				clientCAs := x509.NewCertPool()
+				Expect(clientCAs.AppendCertsFromPEM([]byte(clientCert))).To(BeTrue())
+
+				tlsServer = httptest.NewUnstartedServer(atc.server.Config.Handler)
+				tlsServer.TLS = &tls.Config{
+					ClientAuth: tls.RequireAndVerifyClientCert,
+					ClientCAs:  clientCAs,
+				}
+				tlsServer.StartTLS()
+
+				tlsConfig = concourse.TLSConfig{
+					CACert: string(pem.EncodeToMemory(&pem.Block{
+						Type:  "CERTIFICATE",
+						Bytes: tlsServer.Certificate().Raw,
+					})),
+					ClientCert: clientCert,
+					ClientKey:  clientKey,
+				}
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("verifies the ATC with the CA certificate and presents the client certificate", func() {
+				_, err := apiCommand.Login(tlsServer.URL, team, tlsConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = apiCommand.Pipelines()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when the CA certificate is not provided", func() {
+				BeforeEach(func() {
+					tlsConfig.CACert = ""
+				})
+
+				It("returns an error", func() {
+					_, err := apiCommand.Login(tlsServer.URL, team, tlsConfig)
+					Expect(err).To(MatchError(ContainSubstring("certificate")))
+				})
+			})
+
+			Context("when the client certificate is not provided", func() {
+				BeforeEach(func() {
+					tlsConfig.ClientCert = ""
+					tlsConfig.ClientKey = ""
+				})
+
+				It("returns an error", func() {
+					_, err := apiCommand.Login(tlsServer.URL, team, tlsConfig)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("when the CA certificate is not valid PEM", func() {
+				BeforeEach(func() {
+					tlsConfig.CACert = "some-ca-cert"
+				})
+
+				It("returns an error", func() {
+					_, err := apiCommand.Login(tlsServer.URL, team, tlsConfig)
+					Expect(err).To(MatchError(ContainSubstring("ca_cert")))
+				})
+			})
+		})
+
+		Context("when client credentials are provided", func() {
+			var (
+				tokenServer *httptest.Server
+			)
+
+			BeforeEach(func() {
+				tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					id, secret, _ := r.BasicAuth()
+					if id != "some-client-id" || secret != "some-client-secret" || r.FormValue("grant_type") != "client_credentials" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					w.Write([]byte(`{"token_type":"Bearer","access_token":"some-token"}`))
+				}))
+
+				team = concourse.Team{
+					Name:         "main",
+					ClientID:     "some-client-id",
+					ClientSecret: "some-client-secret",
+					TokenURL:     tokenServer.URL,
+				}
+			})
+
+			AfterEach(func() {
+				tokenServer.Close()
+			})
+
+			It("uses the token obtained from the token url", func() {
+				_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = apiCommand.Pipelines()
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+			})
+
+			Context("when the client credentials are rejected", func() {
+				BeforeEach(func() {
+					team.ClientSecret = "wrong-secret"
+				})
+
+				It("returns an error", func() {
+					_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+					Expect(err).To(HaveOccurred())
+
+					Expect(err.Error()).To(MatchRegexp(".*401.*"))
+				})
+			})
+		})
+
+		Context("when no username or password is specified", func() {
+			It("does not request a token", func() {
+				_, err := apiCommand.Login(atc.server.URL, concourse.Team{Name: team.Name}, concourse.TLSConfig{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = apiCommand.Pipelines()
+				Expect(err).To(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Header.Get("Authorization")).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when login has not been performed", func() {
+		It("returns an error", func() {
+			_, err := apiCommand.Pipelines()
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*login.*"))
+		})
+	})
+
+	Context("when logged in", func() {
+		JustBeforeEach(func() {
+			_, err := apiCommand.Login(atc.server.URL, team, concourse.TLSConfig{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Describe("Pipelines", func() {
+			It("returns the pipeline names", func() {
+				pipelines, err := apiCommand.Pipelines()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(pipelines).To(Equal([]concourse.PipelineRef{
+					{Name: "pipeline-1"},
+					{Name: "pipeline-2"},
+				}))
+			})
+
+			Context("when a pipeline is archived", func() {
+				BeforeEach(func() {
+					atc.archived["pipeline-2"] = true
+				})
+
+				It("leaves it out, as fly does", func() {
+					pipelines, err := apiCommand.Pipelines()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pipelines).To(Equal([]concourse.PipelineRef{
+						{Name: "pipeline-1"},
+					}))
+				})
+			})
+
+			Context("when the team does not exist", func() {
+				BeforeEach(func() {
+					team.Name = "some-other-team"
+				})
+
+				It("returns an error", func() {
+					_, err := apiCommand.Pipelines()
+					Expect(err).To(HaveOccurred())
+
+					Expect(err.Error()).To(MatchRegexp(".*404.*"))
+				})
+			})
+		})
+
+		Describe("GetPipeline", func() {
+			It("returns the config as YAML", func() {
+				output, err := apiCommand.GetPipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(Equal("jobs:\n- name: job-1\nresources: []\n"))
+			})
+
+			Context("when the pipeline does not exist", func() {
+				It("returns an error", func() {
+					_, err := apiCommand.GetPipeline(concourse.PipelineRef{Name: "some-other-pipeline"})
+					Expect(err).To(HaveOccurred())
+
+					Expect(err.Error()).To(MatchRegexp(".*not found.*"))
+				})
+			})
+		})
+
+		Describe("SetPipeline", func() {
+			var (
+				tempDir        string
+				configFilepath string
+			)
+
+			BeforeEach(func() {
+				var err error
+				tempDir, err = ioutil.TempDir("", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				configFilepath = filepath.Join(tempDir, "pipeline.yml")
+				err = ioutil.WriteFile(configFilepath, []byte("jobs:\n- name: ((job_name))\n"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				err := os.RemoveAll(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("updates the config of an existing pipeline with its current version", func() {
+				output, err := apiCommand.SetPipeline(
+					concourse.PipelineRef{Name: "pipeline-1"},
+					configFilepath,
+					nil,
+					map[string]interface{}{"job_name": "some-job"},
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring("WARNING: some warning"))
+				Expect(string(output)).To(ContainSubstring("configuration updated"))
+
+				req, body := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/config"))
+				Expect(req.Header.Get("X-Concourse-Config-Version")).To(Equal("7"))
+				Expect(req.Header.Get("Content-Type")).To(Equal("application/x-yaml"))
+				Expect(body).To(Equal("jobs:\n- name: some-job\n"))
+			})
+
+			It("creates a pipeline which does not exist", func() {
+				output, err := apiCommand.SetPipeline(concourse.PipelineRef{Name: "pipeline-3"}, configFilepath, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring("pipeline created!"))
+
+				req, _ := atc.lastRequest()
+				Expect(req.Header.Get("X-Concourse-Config-Version")).To(BeEmpty())
+			})
+
+			Context("when the config file does not exist", func() {
+				It("returns an error", func() {
+					_, err := apiCommand.SetPipeline(concourse.PipelineRef{Name: "pipeline-1"}, filepath.Join(tempDir, "missing.yml"), nil, nil)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("ValidatePipeline", func() {
+			var (
+				tempDir       string
+				flyBinaryPath string
+			)
+
+			BeforeEach(func() {
+				var err error
+				tempDir, err = ioutil.TempDir("", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				flyBinaryPath = filepath.Join(tempDir, "fake_fly")
+				err = ioutil.WriteFile(flyBinaryPath, []byte(flyScript("bundled", "1.0.0")), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				apiCommand = fly.NewAPICommand(fakeLogger, flyBinaryPath)
+			})
+
+			AfterEach(func() {
+				err := os.RemoveAll(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("runs fly validate-pipeline without any request", func() {
+				atc.Lock()
+				requests := len(atc.requests)
+				atc.Unlock()
+
+				output, err := apiCommand.ValidatePipeline("some-config-file", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(output)).To(Equal("bundled validate-pipeline -c some-config-file\n"))
+
+				atc.Lock()
+				defer atc.Unlock()
+				Expect(atc.requests).To(HaveLen(requests))
+			})
+
+			Context("when strict is true", func() {
+				It("adds --strict flag to command", func() {
+					output, err := apiCommand.ValidatePipeline("some-config-file", true)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(HaveSuffix("validate-pipeline -c some-config-file --strict\n"))
+				})
+			})
+
+			Context("when fly rejects the config", func() {
+				BeforeEach(func() {
+					err := ioutil.WriteFile(flyBinaryPath, []byte("#!/bin/sh\necho some error >&2\nexit 1\n"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("returns an error with the output of fly", func() {
+					_, err := apiCommand.ValidatePipeline("some-config-file", false)
+					Expect(err).To(MatchError(ContainSubstring("some error")))
+				})
+			})
+		})
+
+		Describe("DestroyPipeline", func() {
+			It("deletes the pipeline", func() {
+				_, err := apiCommand.DestroyPipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodDelete))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1"))
+			})
+		})
+
+		Describe("UnpausePipeline", func() {
+			It("unpauses the pipeline", func() {
+				_, err := apiCommand.UnpausePipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/unpause"))
+			})
+
+			Context("when the pipeline has instance vars", func() {
+				It("identifies the instance in the query", func() {
+					_, err := apiCommand.UnpausePipeline(concourse.PipelineRef{
+						Name:         "pipeline-1",
+						InstanceVars: map[string]interface{}{"env": "prod"},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					req, _ := atc.lastRequest()
+					Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/unpause"))
+					Expect(req.URL.Query().Get("vars")).To(Equal(`{"env":"prod"}`))
+				})
+			})
+		})
+
+		Describe("ExposePipeline", func() {
+			It("exposes the pipeline", func() {
+				_, err := apiCommand.ExposePipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/expose"))
+			})
+		})
+
+		Describe("PausePipeline", func() {
+			It("pauses the pipeline", func() {
+				_, err := apiCommand.PausePipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/pause"))
+			})
+		})
+
+		Describe("HidePipeline", func() {
+			It("hides the pipeline", func() {
+				_, err := apiCommand.HidePipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/hide"))
+			})
+		})
+
+		Describe("ArchivePipeline", func() {
+			It("archives the pipeline", func() {
+				_, err := apiCommand.ArchivePipeline(concourse.PipelineRef{Name: "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, _ := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/pipeline-1/archive"))
+			})
+		})
+
+		Describe("OrderPipelines", func() {
+			It("orders the pipelines", func() {
+				_, err := apiCommand.OrderPipelines([]string{"pipeline-2", "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				req, body := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/main/pipelines/ordering"))
+				Expect(body).To(MatchJSON(`["pipeline-2", "pipeline-1"]`))
+			})
+		})
+
+		Describe("Teams", func() {
+			It("returns teams with their auth config", func() {
+				teams, err := apiCommand.Teams()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(teams).To(Equal([]concourse.TeamConfig{
+					{
+						Name: "main",
+						Roles: map[string]concourse.TeamRole{
+							"owner": {Users: []string{"local:admin"}, Groups: []string{}},
+						},
+					},
+				}))
+			})
+		})
+
+		Describe("SetTeam", func() {
+			It("sets the auth config of the team", func() {
+				team := concourse.TeamConfig{
+					Name: "team-1",
+					Roles: map[string]concourse.TeamRole{
+						"member": {Groups: []string{"github:my-org"}},
+					},
+				}
+
+				_, err := apiCommand.SetTeam(team)
+				Expect(err).NotTo(HaveOccurred())
+
+				req, body := atc.lastRequest()
+				Expect(req.Method).To(Equal(http.MethodPut))
+				Expect(req.URL.Path).To(Equal(apiPrefix + "/teams/team-1"))
+				Expect(body).To(MatchJSON(`{"auth":{"member":{"groups":["github:my-org"]}}}`))
+
+				teams, err := apiCommand.Teams()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(teams).To(ContainElement(team))
+			})
+		})
+	})
+})
+
+func stringIn(str string, slice ...string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
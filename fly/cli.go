@@ -0,0 +1,185 @@
+package fly
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+)
+
+// CLICache holds the fly binaries downloaded from the CLI download endpoint
+// of each target, one per Concourse version, so that fly always matches the
+// target without the bundled binary being modified.
+type CLICache struct {
+	dir    string
+	logger logger.Logger
+
+	mu       sync.Mutex
+	resolved map[string]cliLookup
+}
+
+// cliLookup is the outcome of looking up the fly matching a target.
+type cliLookup struct {
+	binaryPath string
+	err        error
+}
+
+// NewCLICache returns a CLICache which keeps its binaries in dir, which is
+// created as needed.
+func NewCLICache(dir string, logger logger.Logger) *CLICache {
+	return &CLICache{
+		dir:      dir,
+		logger:   logger,
+		resolved: make(map[string]cliLookup),
+	}
+}
+
+// Binary returns the path of the fly matching the version of the Concourse at
+// atcURL, which is bundledPath if that fly already matches. Otherwise it is
+// downloaded first if it is not already cached; a downloaded binary is only
+// cached once it reports that version. The binary, or the failure to find
+// it, is only looked up once per atcURL, however many teams log in to it.
+func (c *CLICache) Binary(httpClient *http.Client, atcURL string, bundledPath string) (string, error) {
+	atcURL = strings.TrimRight(atcURL, "/")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := atcURL + " " + bundledPath
+	lookup, found := c.resolved[key]
+	if !found {
+		lookup.binaryPath, lookup.err = c.binary(httpClient, atcURL, bundledPath)
+		c.resolved[key] = lookup
+	}
+
+	return lookup.binaryPath, lookup.err
+}
+
+// binary looks up the fly matching the Concourse at atcURL; the lock must be
+// held.
+func (c *CLICache) binary(httpClient *http.Client, atcURL string, bundledPath string) (string, error) {
+	version, err := atcVersion(httpClient, atcURL)
+	if err != nil {
+		return "", err
+	}
+
+	if verifyCLI(bundledPath, version) == nil {
+		return bundledPath, nil
+	}
+
+	versionDir := filepath.Join(c.dir, url.PathEscape(version))
+	binaryPath := filepath.Join(versionDir, "fly")
+
+	_, err = os.Stat(binaryPath)
+	if err == nil {
+		c.logger.Debugf("Using cached fly %s: %s\n", version, binaryPath)
+		return binaryPath, nil
+	}
+
+	err = os.MkdirAll(versionDir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	downloaded, err := ioutil.TempFile(versionDir, "fly-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(downloaded.Name())
+
+	c.logger.Debugf("Downloading fly %s from %s\n", version, atcURL)
+	err = downloadCLI(httpClient, atcURL, downloaded)
+	downloaded.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Chmod(downloaded.Name(), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = verifyCLI(downloaded.Name(), version)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(downloaded.Name(), binaryPath)
+	if err != nil {
+		return "", err
+	}
+
+	return binaryPath, nil
+}
+
+func atcVersion(httpClient *http.Client, atcURL string) (string, error) {
+	resp, err := httpClient.Get(atcURL + apiPrefix + "/info")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response getting info from %s: %s", atcURL, resp.Status)
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Version == "" {
+		return "", fmt.Errorf("no version in info from %s", atcURL)
+	}
+
+	return info.Version, nil
+}
+
+func downloadCLI(httpClient *http.Client, atcURL string, w io.Writer) error {
+	query := url.Values{
+		"arch":     {runtime.GOARCH},
+		"platform": {runtime.GOOS},
+	}
+
+	resp, err := httpClient.Get(atcURL + apiPrefix + "/cli?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response downloading fly from %s: %s", atcURL, resp.Status)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// verifyCLI checks that the fly at binaryPath runs and is of the given
+// version.
+func verifyCLI(binaryPath string, version string) error {
+	out, err := exec.Command(binaryPath, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("fly %s failed to run: %s", binaryPath, err)
+	}
+
+	if v := strings.TrimSpace(string(out)); v != version {
+		return fmt.Errorf("fly %s is version '%s', not '%s'", binaryPath, v, version)
+	}
+
+	return nil
+}
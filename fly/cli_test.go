@@ -0,0 +1,196 @@
+package fly_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/logger/loggerfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// flyScript returns a fake fly which reports the given version and otherwise
+// echoes its name and args.
+func flyScript(name string, version string) string {
+	return fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo %s
+  exit 0
+fi
+echo %s $@
+`, version, name)
+}
+
+var _ = Describe("CLICache", func() {
+	var (
+		tempDir       string
+		flyBinaryPath string
+
+		atcServer    *httptest.Server
+		atcVersion   string
+		cliVersion   string
+		cliStatus    int
+		cliRequests  []string
+		infoRequests int
+		requestMutex sync.Mutex
+
+		cliCache *fly.CLICache
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		flyBinaryPath = filepath.Join(tempDir, "fake_fly")
+		err = ioutil.WriteFile(flyBinaryPath, []byte(flyScript("bundled", "1.0.0")), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		atcVersion = "2.0.0"
+		cliVersion = "2.0.0"
+		cliStatus = http.StatusOK
+		cliRequests = nil
+		infoRequests = 0
+
+		atcServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case apiPrefix + "/info":
+				requestMutex.Lock()
+				infoRequests++
+				requestMutex.Unlock()
+
+				fmt.Fprintf(w, `{"version":%q}`, atcVersion)
+			case apiPrefix + "/cli":
+				requestMutex.Lock()
+				cliRequests = append(cliRequests, r.URL.RawQuery)
+				requestMutex.Unlock()
+
+				w.WriteHeader(cliStatus)
+				w.Write([]byte(flyScript("downloaded", cliVersion)))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		cliCache = fly.NewCLICache(filepath.Join(tempDir, "cache"), &loggerfakes.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		atcServer.Close()
+
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	login := func(target string) []byte {
+		flyCommand := fly.NewCommand(target, filepath.Join(tempDir, target), &loggerfakes.FakeLogger{}, flyBinaryPath, cliCache)
+
+		output, err := flyCommand.Login(atcServer.URL, concourse.Team{Name: "main"}, concourse.TLSConfig{})
+		Expect(err).NotTo(HaveOccurred())
+
+		return output
+	}
+
+	It("runs the fly downloaded from the target", func() {
+		output := login("some-target")
+		Expect(string(output)).To(HavePrefix("downloaded -t some-target login"))
+
+		Expect(cliRequests).To(Equal([]string{
+			fmt.Sprintf("arch=%s&platform=%s", runtime.GOARCH, runtime.GOOS),
+		}))
+	})
+
+	It("downloads fly once per version", func() {
+		var wg sync.WaitGroup
+		for _, target := range []string{"target-1", "target-2", "target-3"} {
+			wg.Add(1)
+			go func(target string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				output := login(target)
+				Expect(string(output)).To(HavePrefix("downloaded"))
+			}(target)
+		}
+		wg.Wait()
+
+		Expect(cliRequests).To(HaveLen(1))
+	})
+
+	It("looks up the fly matching the target once, however many teams log in", func() {
+		var wg sync.WaitGroup
+		for _, target := range []string{"team-1", "team-2", "team-3"} {
+			wg.Add(1)
+			go func(target string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				login(target)
+			}(target)
+		}
+		wg.Wait()
+
+		Expect(infoRequests).To(Equal(1))
+	})
+
+	It("leaves the bundled fly untouched", func() {
+		login("some-target")
+
+		contents, err := ioutil.ReadFile(flyBinaryPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(flyScript("bundled", "1.0.0")))
+	})
+
+	Context("when the bundled fly matches the target", func() {
+		BeforeEach(func() {
+			atcVersion = "1.0.0"
+		})
+
+		It("runs the bundled fly without downloading", func() {
+			output := login("some-target")
+			Expect(string(output)).To(HavePrefix("bundled"))
+
+			Expect(cliRequests).To(BeEmpty())
+		})
+	})
+
+	Context("when the downloaded fly does not match the target", func() {
+		BeforeEach(func() {
+			cliVersion = "3.0.0"
+		})
+
+		It("falls back to the bundled fly, without caching the download", func() {
+			output := login("some-target")
+			Expect(string(output)).To(HavePrefix("bundled"))
+
+			_, err := os.Stat(filepath.Join(tempDir, "cache", atcVersion, "fly"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("when fly cannot be downloaded", func() {
+		BeforeEach(func() {
+			cliStatus = http.StatusNotFound
+		})
+
+		It("falls back to the bundled fly", func() {
+			output := login("some-target")
+			Expect(string(output)).To(HavePrefix("bundled"))
+		})
+
+		It("does not try again for each team", func() {
+			login("team-1")
+			login("team-2")
+
+			Expect(cliRequests).To(HaveLen(1))
+		})
+	})
+})
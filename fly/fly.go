@@ -4,111 +4,208 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"sort"
 
-	"crypto/tls"
-	"net/http"
-
+	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 )
 
 //go:generate counterfeiter . Command
 
 type Command interface {
-	Login(url string, teamName string, username string, password string, insecure bool) ([]byte, error)
-	Pipelines() ([]string, error)
-	GetPipeline(pipelineName string) ([]byte, error)
-	SetPipeline(pipelineName string, configFilepath string, varsFilepaths []string, vars map[string]interface{}) ([]byte, error)
-	DestroyPipeline(pipelineName string) ([]byte, error)
-	UnpausePipeline(pipelineName string) ([]byte, error)
-	ExposePipeline(pipelineName string) ([]byte, error)
+	Login(url string, team concourse.Team, tlsConfig concourse.TLSConfig) ([]byte, error)
+	Pipelines() ([]concourse.PipelineRef, error)
+	GetPipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	SetPipeline(pipeline concourse.PipelineRef, configFilepath string, varsFilepaths []string, vars map[string]interface{}) ([]byte, error)
+	ValidatePipeline(configFilepath string, strict bool) ([]byte, error)
+	DestroyPipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	UnpausePipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	ExposePipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	PausePipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	HidePipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	ArchivePipeline(pipeline concourse.PipelineRef) ([]byte, error)
+	OrderPipelines(pipelineNames []string) ([]byte, error)
+	Teams() ([]concourse.TeamConfig, error)
+	SetTeam(team concourse.TeamConfig) ([]byte, error)
 }
 
 type command struct {
 	target        string
+	homeDir       string
 	logger        logger.Logger
 	flyBinaryPath string
+	cliCache      *CLICache
+
+	binaryPath string
+	loggedIn   bool
 }
 
-func NewCommand(target string, logger logger.Logger, flyBinaryPath string) Command {
+// NewCommand returns a Command which shells out to fly using the given target.
+// fly is run with homeDir as its home directory, and so its flyrc, unless
+// homeDir is empty. The fly matching the target is taken from cliCache, if
+// not nil, falling back to the fly at flyBinaryPath.
+func NewCommand(
+	target string,
+	homeDir string,
+	logger logger.Logger,
+	flyBinaryPath string,
+	cliCache *CLICache,
+) Command {
 	return &command{
 		target:        target,
+		homeDir:       homeDir,
 		logger:        logger,
 		flyBinaryPath: flyBinaryPath,
+		cliCache:      cliCache,
+		binaryPath:    flyBinaryPath,
 	}
 }
 
-func (f command) Login(
+func (f *command) Login(
 	url string,
-	teamName string,
-	username string,
-	password string,
-	insecure bool,
+	team concourse.Team,
+	tlsConfig concourse.TLSConfig,
 ) ([]byte, error) {
-	args := []string{
-		"login",
-		"-c", url,
-		"-n", teamName,
+	// Until this login succeeds, nothing may run against the previous team
+	f.loggedIn = false
+
+	if f.homeDir != "" {
+		err := os.MkdirAll(f.homeDir, 0700)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if username != "" && password != "" {
-		args = append(args, "-u", username, "-p", password)
+	httpClient, err := newHTTPClient(tlsConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	if insecure {
-		args = append(args, "-k")
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			Proxy:           http.ProxyFromEnvironment,
+	f.binaryPath = f.flyBinaryPath
+	if f.cliCache != nil {
+		binaryPath, err := f.cliCache.Binary(httpClient, url, f.flyBinaryPath)
+		if err != nil {
+			f.logger.Debugf("Using bundled fly, as a matching fly could not be downloaded from %s: %v\n", url, err)
+		} else {
+			f.binaryPath = binaryPath
 		}
-		http.DefaultClient.Transport = tr
 	}
 
-	loginOut, err := f.run(args...)
+	t, err := teamToken(httpClient, team)
 	if err != nil {
 		return nil, err
 	}
 
-	syncOut, err := f.run("sync")
+	certs, err := f.writeCerts(tlsConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	return append(loginOut, syncOut...), nil
+	var loginOut []byte
+	if t != nil {
+		loginOut, err = f.loginWithToken(url, team.Name, tlsConfig, certs, t)
+	} else {
+		loginOut, err = f.loginWithPassword(url, team, tlsConfig, certs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.loggedIn = true
+
+	return loginOut, nil
+}
+
+func (f *command) loginWithPassword(
+	url string,
+	team concourse.Team,
+	tlsConfig concourse.TLSConfig,
+	certs certFiles,
+) ([]byte, error) {
+	args := []string{
+		"login",
+		"-c", url,
+		"-n", team.Name,
+	}
+
+	if team.Username != "" && team.Password != "" {
+		args = append(args, "-u", team.Username, "-p", team.Password)
+	}
+
+	if tlsConfig.Insecure {
+		args = append(args, "-k")
+	}
+
+	if certs.caCert != "" {
+		args = append(args, "--ca-cert", certs.caCert)
+	}
+
+	if certs.clientCert != "" {
+		args = append(args, "--client-cert", certs.clientCert, "--client-key", certs.clientKey)
+	}
+
+	return f.run(args...)
 }
 
-func (f command) Pipelines() ([]string, error) {
-	psOut, err := f.run("pipelines", "--json")
+func (f *command) loginWithToken(
+	url string,
+	teamName string,
+	tlsConfig concourse.TLSConfig,
+	certs certFiles,
+	t *token,
+) ([]byte, error) {
+	if f.target == "" {
+		return nil, fmt.Errorf("target cannot be empty in command.loginWithToken")
+	}
+
+	f.logger.Debugf("Saving token for target: %s\n", f.target)
+	err := saveTarget(f.homeDir, f.target, flyrcTarget{
+		API:            url,
+		Team:           teamName,
+		Insecure:       tlsConfig.Insecure,
+		CACert:         tlsConfig.CACert,
+		ClientCertPath: certs.clientCert,
+		ClientKeyPath:  certs.clientKey,
+		Token: &flyrcToken{
+			Type:  t.Type,
+			Value: t.Value,
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var ps []struct {
-		Name string `json:"name"`
-	}
+	return []byte(fmt.Sprintf("target saved with token for team '%s'\n", teamName)), nil
+}
 
-	err = json.Unmarshal(psOut, &ps)
+func (f *command) Pipelines() ([]concourse.PipelineRef, error) {
+	psOut, err := f.runAsTeam("pipelines", "--json")
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, len(ps))
-	for i, p := range ps {
-		names[i] = p.Name
+	var ps []concourse.PipelineRef
+
+	err = json.Unmarshal(psOut, &ps)
+	if err != nil {
+		return nil, err
 	}
 
-	return names, nil
+	return ps, nil
 }
 
-func (f command) GetPipeline(pipelineName string) ([]byte, error) {
-	return f.run(
+func (f *command) GetPipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
 		"get-pipeline",
-		"-p", pipelineName,
+		"-p", pipeline.String(),
 	)
 }
 
-func (f command) SetPipeline(
-	pipelineName string,
+func (f *command) SetPipeline(
+	pipeline concourse.PipelineRef,
 	configFilepath string,
 	varsFilepaths []string,
 	vars map[string]interface{},
@@ -116,10 +213,26 @@ func (f command) SetPipeline(
 	allArgs := []string{
 		"set-pipeline",
 		"-n",
-		"-p", pipelineName,
+		"-p", pipeline.Name,
 		"-c", configFilepath,
 	}
 
+	instanceVarKeys := make([]string, 0, len(pipeline.InstanceVars))
+	for key := range pipeline.InstanceVars {
+		instanceVarKeys = append(instanceVarKeys, key)
+	}
+	sort.Strings(instanceVarKeys)
+
+	for _, key := range instanceVarKeys {
+		payload, err := json.Marshal(pipeline.InstanceVars[key])
+
+		if err != nil {
+			return nil, err
+		}
+
+		allArgs = append(allArgs, "-i", fmt.Sprintf("%s=%s", key, payload))
+	}
+
 	for _, vf := range varsFilepaths {
 		allArgs = append(allArgs, "-l", vf)
 	}
@@ -134,32 +247,120 @@ func (f command) SetPipeline(
 		allArgs = append(allArgs, "-y", fmt.Sprintf("%s=%s", key, payload))
 	}
 
-	return f.run(allArgs...)
+	return f.runAsTeam(allArgs...)
 }
 
-func (f command) UnpausePipeline(pipelineName string) ([]byte, error) {
-	return f.run(
+func (f *command) UnpausePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
 		"unpause-pipeline",
-		"-p", pipelineName,
+		"-p", pipeline.String(),
 	)
 }
 
-func (f command) DestroyPipeline(pipelineName string) ([]byte, error) {
-	return f.run(
+func (f *command) ValidatePipeline(configFilepath string, strict bool) ([]byte, error) {
+	args := []string{
+		"validate-pipeline",
+		"-c", configFilepath,
+	}
+
+	if strict {
+		args = append(args, "--strict")
+	}
+
+	return f.run(args...)
+}
+
+func (f *command) DestroyPipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
 		"destroy-pipeline",
 		"-n",
-		"-p", pipelineName,
+		"-p", pipeline.String(),
 	)
 }
 
-func (f command) ExposePipeline(pipelineName string) ([]byte, error) {
-	return f.run(
+func (f *command) ExposePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
 		"expose-pipeline",
-		"-p", pipelineName,
+		"-p", pipeline.String(),
+	)
+}
+
+func (f *command) PausePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
+		"pause-pipeline",
+		"-p", pipeline.String(),
+	)
+}
+
+func (f *command) HidePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
+		"hide-pipeline",
+		"-p", pipeline.String(),
+	)
+}
+
+func (f *command) ArchivePipeline(pipeline concourse.PipelineRef) ([]byte, error) {
+	return f.runAsTeam(
+		"archive-pipeline",
+		"-n",
+		"-p", pipeline.String(),
 	)
 }
 
-func (f command) run(args ...string) ([]byte, error) {
+func (f *command) OrderPipelines(pipelineNames []string) ([]byte, error) {
+	args := []string{"order-pipelines"}
+	for _, name := range pipelineNames {
+		args = append(args, "-p", name)
+	}
+
+	return f.runAsTeam(args...)
+}
+
+func (f *command) Teams() ([]concourse.TeamConfig, error) {
+	teamsOut, err := f.runAsTeam("teams", "--details", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	return teamConfigs(teamsOut)
+}
+
+func (f *command) SetTeam(team concourse.TeamConfig) ([]byte, error) {
+	config, err := flyTeamConfig(team)
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := ioutil.TempFile("", "concourse-pipeline-resource-team")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(configFile.Name())
+
+	_, err = configFile.Write(config)
+	configFile.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.runAsTeam(
+		"set-team",
+		"--team-name", team.Name,
+		"--config", configFile.Name(),
+		"--non-interactive",
+	)
+}
+
+// runAsTeam runs fly as run does, provided that the last login succeeded.
+func (f *command) runAsTeam(args ...string) ([]byte, error) {
+	if !f.loggedIn {
+		return nil, fmt.Errorf("login must succeed before running fly %s", args[0])
+	}
+
+	return f.run(args...)
+}
+
+func (f *command) run(args ...string) ([]byte, error) {
 	if f.target == "" {
 		return nil, fmt.Errorf("target cannot be empty in command.run")
 	}
@@ -167,8 +368,17 @@ func (f command) run(args ...string) ([]byte, error) {
 	defaultArgs := []string{
 		"-t", f.target,
 	}
-	allArgs := append(defaultArgs, args...)
-	cmd := exec.Command(f.flyBinaryPath, allArgs...)
+
+	return runFly(f.logger, f.binaryPath, f.homeDir, append(defaultArgs, args...)...)
+}
+
+// runFly runs the fly at binaryPath with homeDir as its home directory,
+// unless it is empty, returning its stdout, and its stderr in any error.
+func runFly(logger logger.Logger, binaryPath string, homeDir string, args ...string) ([]byte, error) {
+	cmd := exec.Command(binaryPath, args...)
+	if homeDir != "" {
+		cmd.Env = append(os.Environ(), "HOME="+homeDir)
+	}
 
 	outbuf := bytes.NewBuffer(nil)
 	errbuf := bytes.NewBuffer(nil)
@@ -176,14 +386,14 @@ func (f command) run(args ...string) ([]byte, error) {
 	cmd.Stdout = outbuf
 	cmd.Stderr = errbuf
 
-	f.logger.Debugf("Starting fly command: %v\n", allArgs)
+	logger.Debugf("Starting fly command: %v\n", args)
 	err := cmd.Start()
 	if err != nil {
 		// If the command was never started, there will be nothing in the buffers
 		return nil, err
 	}
 
-	f.logger.Debugf("Waiting for fly command: %v\n", allArgs)
+	logger.Debugf("Waiting for fly command: %v\n", args)
 	err = cmd.Wait()
 	if err != nil {
 		if len(errbuf.Bytes()) > 0 {
@@ -3,9 +3,13 @@ package fly_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger/loggerfakes"
 	. "github.com/onsi/ginkgo"
@@ -54,7 +58,7 @@ var _ = Describe("Command", func() {
 		err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
 		Expect(err).NotTo(HaveOccurred())
 
-		flyCommand = fly.NewCommand(target, fakeLogger, flyBinaryPath)
+		flyCommand = fly.NewCommand(target, tempDir, fakeLogger, flyBinaryPath, nil)
 	})
 
 	AfterEach(func() {
@@ -64,33 +68,53 @@ var _ = Describe("Command", func() {
 
 	Describe("Login", func() {
 		var (
-			url      string
-			username string
-			password string
-			insecure bool
+			url          string
+			username     string
+			password     string
+			token        string
+			clientID     string
+			clientSecret string
+			tokenURL     string
+			tlsConfig    concourse.TLSConfig
+
+			team concourse.Team
 		)
 
 		BeforeEach(func() {
 			url = "some-url"
 			username = "some-username"
 			password = "some-password"
-			insecure = false
+			token = ""
+			clientID = ""
+			clientSecret = ""
+			tokenURL = ""
+			tlsConfig = concourse.TLSConfig{}
+		})
+
+		JustBeforeEach(func() {
+			team = concourse.Team{
+				Name:         teamName,
+				Username:     username,
+				Password:     password,
+				Token:        token,
+				ClientID:     clientID,
+				ClientSecret: clientSecret,
+				TokenURL:     tokenURL,
+			}
 		})
 
 		It("returns output without error", func() {
-			output, err := flyCommand.Login(url, teamName, username, password, insecure)
+			output, err := flyCommand.Login(url, team, tlsConfig)
 			Expect(err).NotTo(HaveOccurred())
 
 			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
+				"%s %s %s %s %s %s %s %s %s %s %s\n",
 				"-t", target,
 				"login",
 				"-c", url,
 				"-n", teamName,
 				"-u", username,
 				"-p", password,
-				"-t", target,
-				"sync",
 			)
 
 			Expect(string(output)).To(Equal(expectedOutput))
@@ -98,15 +122,15 @@ var _ = Describe("Command", func() {
 
 		Context("when insecure is true", func() {
 			BeforeEach(func() {
-				insecure = true
+				tlsConfig.Insecure = true
 			})
 
 			It("adds -k flag to command", func() {
-				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				output, err := flyCommand.Login(url, team, tlsConfig)
 				Expect(err).NotTo(HaveOccurred())
 
 				expectedOutput := fmt.Sprintf(
-					"%s %s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
+					"%s %s %s %s %s %s %s %s %s %s %s %s\n",
 					"-t", target,
 					"login",
 					"-c", url,
@@ -114,21 +138,73 @@ var _ = Describe("Command", func() {
 					"-u", username,
 					"-p", password,
 					"-k",
-					"-t", target,
-					"sync",
 				)
 
 				Expect(string(output)).To(Equal(expectedOutput))
 			})
 		})
 
+		Context("when a CA certificate and client certificate are provided", func() {
+			var (
+				clientCert string
+				clientKey  string
+			)
+
+			BeforeEach(func() {
+				clientCert, clientKey = selfSignedCert()
+
+				tlsConfig = concourse.TLSConfig{
+					CACert:     clientCert,
+					ClientCert: clientCert,
+					ClientKey:  clientKey,
+				}
+			})
+
+			It("passes them to fly as files", func() {
+				output, err := flyCommand.Login(url, team, tlsConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				caCertPath := filepath.Join(tempDir, ".some-target-ca.pem")
+				clientCertPath := filepath.Join(tempDir, ".some-target-client-cert.pem")
+				clientKeyPath := filepath.Join(tempDir, ".some-target-client-key.pem")
+
+				Expect(string(output)).To(ContainSubstring(
+					"--ca-cert %s --client-cert %s --client-key %s",
+					caCertPath,
+					clientCertPath,
+					clientKeyPath,
+				))
+
+				for path, contents := range map[string]string{
+					caCertPath:     clientCert,
+					clientCertPath: clientCert,
+					clientKeyPath:  clientKey,
+				} {
+					b, err := ioutil.ReadFile(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(b)).To(Equal(contents))
+				}
+			})
+
+			Context("when the client key does not match the client certificate", func() {
+				BeforeEach(func() {
+					_, tlsConfig.ClientKey = selfSignedCert()
+				})
+
+				It("returns an error", func() {
+					_, err := flyCommand.Login(url, team, tlsConfig)
+					Expect(err).To(MatchError(ContainSubstring("client_key")))
+				})
+			})
+		})
+
 		Context("when there is an error starting the commmand", func() {
 			BeforeEach(func() {
 				fakeFlyContents = ""
 			})
 
 			It("returns an error", func() {
-				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+				_, err := flyCommand.Login(url, team, tlsConfig)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -140,17 +216,15 @@ var _ = Describe("Command", func() {
 			})
 
 			It("does not pass the `p` or `u` flags to fly", func() {
-				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				output, err := flyCommand.Login(url, team, tlsConfig)
 				Expect(err).NotTo(HaveOccurred())
 
 				expectedOutput := fmt.Sprintf(
-					"%s %s %s %s %s %s %s\n%s %s %s\n",
+					"%s %s %s %s %s %s %s\n",
 					"-t", target,
 					"login",
 					"-c", url,
 					"-n", teamName,
-					"-t", target,
-					"sync",
 				)
 
 				Expect(string(output)).To(Equal(expectedOutput))
@@ -163,212 +237,614 @@ var _ = Describe("Command", func() {
 			})
 
 			It("appends stderr to the error", func() {
-				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+				_, err := flyCommand.Login(url, team, tlsConfig)
 				Expect(err).To(HaveOccurred())
 
 				Expect(err.Error()).To(MatchRegexp(".*some err output.*"))
 			})
 		})
-	})
 
-	Describe("Pipelines", func() {
-		BeforeEach(func() {
-			fakeFlyContents = `#!/bin/sh
-echo '[{"name":"abc"},{"name":"def"}]'
-`
+		Context("when a token is provided", func() {
+			BeforeEach(func() {
+				username = ""
+				password = ""
+				token = "some-token"
+
+				err := ioutil.WriteFile(
+					filepath.Join(tempDir, ".flyrc"),
+					[]byte("targets:\n  other-target:\n    api: other-url\n    team: other-team\n"),
+					os.ModePerm,
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("saves the token to the flyrc instead of logging in", func() {
+				output, err := flyCommand.Login(url, team, tlsConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(Equal(fmt.Sprintf("target saved with token for team '%s'\n", teamName)))
+
+				flyrc, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(flyrc)).To(ContainSubstring("other-target"))
+				Expect(string(flyrc)).To(ContainSubstring(
+					"  %s:\n    api: %s\n    team: %s\n    token:\n      type: Bearer\n      value: %s\n",
+					target,
+					url,
+					teamName,
+					token,
+				))
+			})
+
+			Context("when insecure is true", func() {
+				BeforeEach(func() {
+					tlsConfig.Insecure = true
+				})
+
+				It("saves the target as insecure", func() {
+					_, err := flyCommand.Login(url, team, tlsConfig)
+					Expect(err).NotTo(HaveOccurred())
+
+					flyrc, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(flyrc)).To(ContainSubstring("insecure: true"))
+				})
+			})
+
+			Context("when a CA certificate and client certificate are provided", func() {
+				BeforeEach(func() {
+					clientCert, clientKey := selfSignedCert()
+
+					tlsConfig = concourse.TLSConfig{
+						CACert:     clientCert,
+						ClientCert: clientCert,
+						ClientKey:  clientKey,
+					}
+				})
+
+				It("saves them with the target", func() {
+					_, err := flyCommand.Login(url, team, tlsConfig)
+					Expect(err).NotTo(HaveOccurred())
+
+					flyrc, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(flyrc)).To(ContainSubstring("ca_cert: |\n      -----BEGIN CERTIFICATE-----"))
+					Expect(string(flyrc)).To(ContainSubstring("client_cert_path: %s", filepath.Join(tempDir, ".some-target-client-cert.pem")))
+					Expect(string(flyrc)).To(ContainSubstring("client_key_path: %s", filepath.Join(tempDir, ".some-target-client-key.pem")))
+				})
+			})
 		})
 
-		It("returns pipelines without error", func() {
-			pipelines, err := flyCommand.Pipelines()
-			Expect(err).NotTo(HaveOccurred())
+		Context("when client credentials are provided", func() {
+			var (
+				tokenServer *httptest.Server
+			)
 
-			Expect(pipelines).To(Equal([]string{"abc", "def"}))
+			BeforeEach(func() {
+				username = ""
+				password = ""
+				clientID = "some-client-id"
+				clientSecret = "some-client-secret"
+
+				tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					id, secret, _ := r.BasicAuth()
+					if id != "some-client-id" || secret != "some-client-secret" || r.FormValue("grant_type") != "client_credentials" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					w.Write([]byte(`{"token_type":"bearer","access_token":"some-access-token"}`))
+				}))
+				tokenURL = tokenServer.URL
+			})
+
+			AfterEach(func() {
+				tokenServer.Close()
+			})
+
+			It("saves the token obtained from the token url to the flyrc", func() {
+				_, err := flyCommand.Login(url, team, tlsConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				flyrc, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(flyrc)).To(ContainSubstring("type: bearer\n      value: some-access-token"))
+			})
+
+			Context("when the client credentials are rejected", func() {
+				BeforeEach(func() {
+					clientSecret = "wrong-secret"
+				})
+
+				It("returns an error", func() {
+					_, err := flyCommand.Login(url, team, tlsConfig)
+					Expect(err).To(HaveOccurred())
+
+					Expect(err.Error()).To(MatchRegexp(".*401.*"))
+				})
+			})
 		})
 	})
 
-	Describe("GetPipeline", func() {
-		var (
-			pipelineName string
-		)
-
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+	Context("when not logged in", func() {
+		It("returns an error rather than running fly", func() {
+			_, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+			Expect(err).To(MatchError(ContainSubstring("login must succeed")))
 		})
+	})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.GetPipeline(pipelineName)
+	Context("when the last login failed", func() {
+		JustBeforeEach(func() {
+			_, err := flyCommand.Login("some-url", concourse.Team{Name: teamName}, concourse.TLSConfig{})
 			Expect(err).NotTo(HaveOccurred())
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
-				"-t", target,
-				"get-pipeline",
-				"-p", pipelineName,
-			)
+			err = ioutil.WriteFile(flyBinaryPath, []byte(errScript), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
 
-			Expect(string(output)).To(Equal(expectedOutput))
+			_, err = flyCommand.Login("some-url", concourse.Team{Name: "other-team"}, concourse.TLSConfig{})
+			Expect(err).To(HaveOccurred())
+
+			err = ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
 		})
-	})
 
-	Describe("SetPipeline", func() {
-		var (
-			pipelineName   string
-			configFilepath string
-		)
+		It("returns an error rather than running fly against the previous team", func() {
+			_, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+			Expect(err).To(MatchError(ContainSubstring("login must succeed")))
+		})
+	})
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
-			configFilepath = "some-config-file"
+	Context("when logged in", func() {
+		JustBeforeEach(func() {
+			_, err := flyCommand.Login("some-url", concourse.Team{Name: teamName}, concourse.TLSConfig{})
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.SetPipeline(pipelineName, configFilepath, nil, nil)
+		It("runs fly with the private home directory", func() {
+			err := ioutil.WriteFile(flyBinaryPath, []byte("#!/bin/sh\necho $HOME"), os.ModePerm)
 			Expect(err).NotTo(HaveOccurred())
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s %s %s %s\n",
-				"-t", target,
-				"set-pipeline",
-				"-n",
-				"-p", pipelineName,
-				"-c", configFilepath,
-			)
+			output, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
 
-			Expect(string(output)).To(Equal(expectedOutput))
+			Expect(string(output)).To(Equal(tempDir + "\n"))
 		})
 
-		Context("when optional vars are provided", func() {
+		Describe("Pipelines", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+	echo '[{"name":"abc"},{"name":"def","instance_vars":{"env":"prod"}}]'
+	`
+			})
+
+			It("returns pipelines without error", func() {
+				pipelines, err := flyCommand.Pipelines()
+				Expect(err).NotTo(HaveOccurred())
 
+				Expect(pipelines).To(Equal([]concourse.PipelineRef{
+					{Name: "abc"},
+					{Name: "def", InstanceVars: map[string]interface{}{"env": "prod"}},
+				}))
+			})
+		})
+
+		Describe("GetPipeline", func() {
 			var (
-				vars map[string]interface{}
+				pipelineName string
 			)
 
 			BeforeEach(func() {
-				vars = map[string]interface{}{
-					"launch-missiles": true,
-					"credentials": map[string]string{
-						"username": "admin",
-						"password": "admin",
-					},
-				}
+				pipelineName = "some-pipeline"
 			})
 
 			It("returns output without error", func() {
-				output, err := flyCommand.SetPipeline(pipelineName, configFilepath, nil, vars)
+				output, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: pipelineName})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(string(output)).To(HavePrefix("-t %s set-pipeline", target))
-				Expect(string(output)).To(ContainSubstring("-n"))
-				Expect(string(output)).To(ContainSubstring("-p %s", pipelineName))
-				Expect(string(output)).To(ContainSubstring("-c %s", configFilepath))
-				Expect(string(output)).To(ContainSubstring("-y launch-missiles=true"))
-				Expect(string(output)).To(ContainSubstring("-y credentials={\"password\":\"admin\",\"username\":\"admin\"}"))
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"get-pipeline",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
 			})
-		})
 
-		Context("when optional vars files are provided", func() {
+			Context("when the pipeline has instance vars", func() {
+				It("identifies the instance by its ref", func() {
+					output, err := flyCommand.GetPipeline(concourse.PipelineRef{
+						Name: pipelineName,
+						InstanceVars: map[string]interface{}{
+							"region": "eu-west",
+							"env":    "prod",
+							"shard":  1,
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(ContainSubstring("-p %s/env:prod,region:eu-west,shard:1\n", pipelineName))
+				})
+			})
+		})
 
+		Describe("SetPipeline", func() {
 			var (
-				varsFiles []string
+				pipelineName   string
+				configFilepath string
 			)
 
 			BeforeEach(func() {
-				varsFiles = []string{
-					"vars-file-1",
-					"vars-file-2",
-				}
+				pipelineName = "some-pipeline"
+				configFilepath = "some-config-file"
 			})
 
 			It("returns output without error", func() {
-				output, err := flyCommand.SetPipeline(pipelineName, configFilepath, varsFiles, nil)
+				output, err := flyCommand.SetPipeline(concourse.PipelineRef{Name: pipelineName}, configFilepath, nil, nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				expectedOutput := fmt.Sprintf(
-					"%s %s %s %s %s %s %s %s %s %s %s %s\n",
+					"%s %s %s %s %s %s %s %s\n",
 					"-t", target,
 					"set-pipeline",
 					"-n",
 					"-p", pipelineName,
 					"-c", configFilepath,
-					"-l", varsFiles[0],
-					"-l", varsFiles[1],
 				)
 
 				Expect(string(output)).To(Equal(expectedOutput))
 			})
+
+			Context("when optional vars are provided", func() {
+
+				var (
+					vars map[string]interface{}
+				)
+
+				BeforeEach(func() {
+					vars = map[string]interface{}{
+						"launch-missiles": true,
+						"credentials": map[string]string{
+							"username": "admin",
+							"password": "admin",
+						},
+					}
+				})
+
+				It("returns output without error", func() {
+					output, err := flyCommand.SetPipeline(concourse.PipelineRef{Name: pipelineName}, configFilepath, nil, vars)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(HavePrefix("-t %s set-pipeline", target))
+					Expect(string(output)).To(ContainSubstring("-n"))
+					Expect(string(output)).To(ContainSubstring("-p %s", pipelineName))
+					Expect(string(output)).To(ContainSubstring("-c %s", configFilepath))
+					Expect(string(output)).To(ContainSubstring("-y launch-missiles=true"))
+					Expect(string(output)).To(ContainSubstring("-y credentials={\"password\":\"admin\",\"username\":\"admin\"}"))
+				})
+			})
+
+			Context("when optional vars files are provided", func() {
+
+				var (
+					varsFiles []string
+				)
+
+				BeforeEach(func() {
+					varsFiles = []string{
+						"vars-file-1",
+						"vars-file-2",
+					}
+				})
+
+				It("returns output without error", func() {
+					output, err := flyCommand.SetPipeline(concourse.PipelineRef{Name: pipelineName}, configFilepath, varsFiles, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					expectedOutput := fmt.Sprintf(
+						"%s %s %s %s %s %s %s %s %s %s %s %s\n",
+						"-t", target,
+						"set-pipeline",
+						"-n",
+						"-p", pipelineName,
+						"-c", configFilepath,
+						"-l", varsFiles[0],
+						"-l", varsFiles[1],
+					)
+
+					Expect(string(output)).To(Equal(expectedOutput))
+				})
+			})
+
+			Context("when the pipeline has instance vars", func() {
+				It("sets the instance vars separately from the pipeline name", func() {
+					output, err := flyCommand.SetPipeline(
+						concourse.PipelineRef{
+							Name:         pipelineName,
+							InstanceVars: map[string]interface{}{"region": "eu", "env": "prod"},
+						},
+						configFilepath,
+						nil,
+						nil,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(ContainSubstring(
+						"-p %s -c %s -i env=\"prod\" -i region=\"eu\"\n",
+						pipelineName,
+						configFilepath,
+					))
+				})
+			})
 		})
-	})
 
-	Describe("DestroyPipeline", func() {
-		var (
-			pipelineName string
-		)
+		Describe("ValidatePipeline", func() {
+			It("returns output without error", func() {
+				output, err := flyCommand.ValidatePipeline("some-config-file", false)
+				Expect(err).NotTo(HaveOccurred())
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"validate-pipeline",
+					"-c", "some-config-file",
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+
+			Context("when strict is true", func() {
+				It("adds --strict flag to command", func() {
+					output, err := flyCommand.ValidatePipeline("some-config-file", true)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(HaveSuffix("validate-pipeline -c some-config-file --strict\n"))
+				})
+			})
 		})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.DestroyPipeline(pipelineName)
-			Expect(err).NotTo(HaveOccurred())
+		Describe("DestroyPipeline", func() {
+			var (
+				pipelineName string
+			)
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s %s\n",
-				"-t", target,
-				"destroy-pipeline",
-				"-n",
-				"-p", pipelineName,
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.DestroyPipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s %s\n",
+					"-t", target,
+					"destroy-pipeline",
+					"-n",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+		})
+
+		Describe("UnpausePipeline", func() {
+			var (
+				pipelineName string
 			)
 
-			Expect(string(output)).To(Equal(expectedOutput))
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.UnpausePipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"unpause-pipeline",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
 		})
-	})
 
-	Describe("UnpausePipeline", func() {
-		var (
-			pipelineName string
-		)
+		Describe("ExposePipeline", func() {
+			var (
+				pipelineName string
+			)
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.ExposePipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"expose-pipeline",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
 		})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.UnpausePipeline(pipelineName)
-			Expect(err).NotTo(HaveOccurred())
+		Describe("PausePipeline", func() {
+			var (
+				pipelineName string
+			)
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
-				"-t", target,
-				"unpause-pipeline",
-				"-p", pipelineName,
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.PausePipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"pause-pipeline",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+		})
+
+		Describe("HidePipeline", func() {
+			var (
+				pipelineName string
 			)
 
-			Expect(string(output)).To(Equal(expectedOutput))
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.HidePipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s\n",
+					"-t", target,
+					"hide-pipeline",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
 		})
-	})
 
-	Describe("ExposePipeline", func() {
-		var (
-			pipelineName string
-		)
+		Describe("ArchivePipeline", func() {
+			var (
+				pipelineName string
+			)
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+			BeforeEach(func() {
+				pipelineName = "some-pipeline"
+			})
+
+			It("returns output without error", func() {
+				output, err := flyCommand.ArchivePipeline(concourse.PipelineRef{Name: pipelineName})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s %s\n",
+					"-t", target,
+					"archive-pipeline",
+					"-n",
+					"-p", pipelineName,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
 		})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.ExposePipeline(pipelineName)
-			Expect(err).NotTo(HaveOccurred())
+		Describe("OrderPipelines", func() {
+			It("returns output without error", func() {
+				output, err := flyCommand.OrderPipelines([]string{"pipeline-2", "pipeline-1"})
+				Expect(err).NotTo(HaveOccurred())
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
-				"-t", target,
-				"expose-pipeline",
-				"-p", pipelineName,
-			)
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s %s %s\n",
+					"-t", target,
+					"order-pipelines",
+					"-p", "pipeline-2",
+					"-p", "pipeline-1",
+				)
 
-			Expect(string(output)).To(Equal(expectedOutput))
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+		})
+
+		Describe("Teams", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+	echo '[{"id":1,"name":"main","auth":{"owner":{"users":["local:admin"],"groups":[]}}},{"id":2,"name":"team-1","auth":{"member":{"groups":["github:my-org"]}}}]'
+	`
+			})
+
+			It("returns teams with their auth config without error", func() {
+				teams, err := flyCommand.Teams()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(teams).To(Equal([]concourse.TeamConfig{
+					{
+						Name: "main",
+						Roles: map[string]concourse.TeamRole{
+							"owner": {Users: []string{"local:admin"}, Groups: []string{}},
+						},
+					},
+					{
+						Name: "team-1",
+						Roles: map[string]concourse.TeamRole{
+							"member": {Groups: []string{"github:my-org"}},
+						},
+					},
+				}))
+			})
+		})
+
+		Describe("SetTeam", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+	echo $@
+	while [ $# -gt 0 ]; do
+		if [ "$1" = "--config" ]; then
+			cat "$2"
+		fi
+		shift
+	done
+	`
+			})
+
+			It("sets the team with its auth config keyed by connector", func() {
+				output, err := flyCommand.SetTeam(concourse.TeamConfig{
+					Name: "team-1",
+					Roles: map[string]concourse.TeamRole{
+						"owner": {
+							Users:  []string{"local:admin"},
+							Groups: []string{"github:my-org:my-team", "oidc:admins"},
+						},
+						"viewer": {
+							Groups: []string{"github:my-org"},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				lines := strings.SplitN(string(output), "\n", 2)
+				Expect(lines[0]).To(MatchRegexp(
+					"^-t %s set-team --team-name team-1 --config .+ --non-interactive$",
+					target,
+				))
+				Expect(lines[1]).To(MatchYAML(`
+roles:
+- name: owner
+  local:
+    users: [admin]
+  github:
+    teams: ["my-org:my-team"]
+  oidc:
+    groups: [admins]
+- name: viewer
+  github:
+    orgs: [my-org]
+`))
+			})
 		})
 	})
 })
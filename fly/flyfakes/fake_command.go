@@ -4,14 +4,28 @@ package flyfakes
 import (
 	"sync"
 
+	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 )
 
 type FakeCommand struct {
-	DestroyPipelineStub        func(string) ([]byte, error)
+	ArchivePipelineStub        func(concourse.PipelineRef) ([]byte, error)
+	archivePipelineMutex       sync.RWMutex
+	archivePipelineArgsForCall []struct {
+		arg1 concourse.PipelineRef
+	}
+	archivePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	archivePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	DestroyPipelineStub        func(concourse.PipelineRef) ([]byte, error)
 	destroyPipelineMutex       sync.RWMutex
 	destroyPipelineArgsForCall []struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}
 	destroyPipelineReturns struct {
 		result1 []byte
@@ -21,10 +35,10 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	ExposePipelineStub        func(string) ([]byte, error)
+	ExposePipelineStub        func(concourse.PipelineRef) ([]byte, error)
 	exposePipelineMutex       sync.RWMutex
 	exposePipelineArgsForCall []struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}
 	exposePipelineReturns struct {
 		result1 []byte
@@ -34,10 +48,10 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	GetPipelineStub        func(string) ([]byte, error)
+	GetPipelineStub        func(concourse.PipelineRef) ([]byte, error)
 	getPipelineMutex       sync.RWMutex
 	getPipelineArgsForCall []struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}
 	getPipelineReturns struct {
 		result1 []byte
@@ -47,14 +61,25 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	LoginStub        func(string, string, string, string, bool) ([]byte, error)
+	HidePipelineStub        func(concourse.PipelineRef) ([]byte, error)
+	hidePipelineMutex       sync.RWMutex
+	hidePipelineArgsForCall []struct {
+		arg1 concourse.PipelineRef
+	}
+	hidePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	hidePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	LoginStub        func(string, concourse.Team, concourse.TLSConfig) ([]byte, error)
 	loginMutex       sync.RWMutex
 	loginArgsForCall []struct {
 		arg1 string
-		arg2 string
-		arg3 string
-		arg4 string
-		arg5 bool
+		arg2 concourse.Team
+		arg3 concourse.TLSConfig
 	}
 	loginReturns struct {
 		result1 []byte
@@ -64,22 +89,48 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	PipelinesStub        func() ([]string, error)
+	OrderPipelinesStub        func([]string) ([]byte, error)
+	orderPipelinesMutex       sync.RWMutex
+	orderPipelinesArgsForCall []struct {
+		arg1 []string
+	}
+	orderPipelinesReturns struct {
+		result1 []byte
+		result2 error
+	}
+	orderPipelinesReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	PausePipelineStub        func(concourse.PipelineRef) ([]byte, error)
+	pausePipelineMutex       sync.RWMutex
+	pausePipelineArgsForCall []struct {
+		arg1 concourse.PipelineRef
+	}
+	pausePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	pausePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	PipelinesStub        func() ([]concourse.PipelineRef, error)
 	pipelinesMutex       sync.RWMutex
 	pipelinesArgsForCall []struct {
 	}
 	pipelinesReturns struct {
-		result1 []string
+		result1 []concourse.PipelineRef
 		result2 error
 	}
 	pipelinesReturnsOnCall map[int]struct {
-		result1 []string
+		result1 []concourse.PipelineRef
 		result2 error
 	}
-	SetPipelineStub        func(string, string, []string, map[string]interface{}) ([]byte, error)
+	SetPipelineStub        func(concourse.PipelineRef, string, []string, map[string]interface{}) ([]byte, error)
 	setPipelineMutex       sync.RWMutex
 	setPipelineArgsForCall []struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 		arg2 string
 		arg3 []string
 		arg4 map[string]interface{}
@@ -92,10 +143,35 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	UnpausePipelineStub        func(string) ([]byte, error)
+	SetTeamStub        func(concourse.TeamConfig) ([]byte, error)
+	setTeamMutex       sync.RWMutex
+	setTeamArgsForCall []struct {
+		arg1 concourse.TeamConfig
+	}
+	setTeamReturns struct {
+		result1 []byte
+		result2 error
+	}
+	setTeamReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	TeamsStub        func() ([]concourse.TeamConfig, error)
+	teamsMutex       sync.RWMutex
+	teamsArgsForCall []struct {
+	}
+	teamsReturns struct {
+		result1 []concourse.TeamConfig
+		result2 error
+	}
+	teamsReturnsOnCall map[int]struct {
+		result1 []concourse.TeamConfig
+		result2 error
+	}
+	UnpausePipelineStub        func(concourse.PipelineRef) ([]byte, error)
 	unpausePipelineMutex       sync.RWMutex
 	unpausePipelineArgsForCall []struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}
 	unpausePipelineReturns struct {
 		result1 []byte
@@ -105,25 +181,104 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
+	ValidatePipelineStub        func(string, bool) ([]byte, error)
+	validatePipelineMutex       sync.RWMutex
+	validatePipelineArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	validatePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	validatePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeCommand) DestroyPipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) ArchivePipeline(arg1 concourse.PipelineRef) ([]byte, error) {
+	fake.archivePipelineMutex.Lock()
+	ret, specificReturn := fake.archivePipelineReturnsOnCall[len(fake.archivePipelineArgsForCall)]
+	fake.archivePipelineArgsForCall = append(fake.archivePipelineArgsForCall, struct {
+		arg1 concourse.PipelineRef
+	}{arg1})
+	stub := fake.ArchivePipelineStub
+	fakeReturns := fake.archivePipelineReturns
+	fake.recordInvocation("ArchivePipeline", []interface{}{arg1})
+	fake.archivePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) ArchivePipelineCallCount() int {
+	fake.archivePipelineMutex.RLock()
+	defer fake.archivePipelineMutex.RUnlock()
+	return len(fake.archivePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) ArchivePipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = stub
+}
+
+func (fake *FakeCommand) ArchivePipelineArgsForCall(i int) concourse.PipelineRef {
+	fake.archivePipelineMutex.RLock()
+	defer fake.archivePipelineMutex.RUnlock()
+	argsForCall := fake.archivePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) ArchivePipelineReturns(result1 []byte, result2 error) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = nil
+	fake.archivePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) ArchivePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = nil
+	if fake.archivePipelineReturnsOnCall == nil {
+		fake.archivePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.archivePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) DestroyPipeline(arg1 concourse.PipelineRef) ([]byte, error) {
 	fake.destroyPipelineMutex.Lock()
 	ret, specificReturn := fake.destroyPipelineReturnsOnCall[len(fake.destroyPipelineArgsForCall)]
 	fake.destroyPipelineArgsForCall = append(fake.destroyPipelineArgsForCall, struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}{arg1})
+	stub := fake.DestroyPipelineStub
+	fakeReturns := fake.destroyPipelineReturns
 	fake.recordInvocation("DestroyPipeline", []interface{}{arg1})
 	fake.destroyPipelineMutex.Unlock()
-	if fake.DestroyPipelineStub != nil {
-		return fake.DestroyPipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.destroyPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -133,13 +288,13 @@ func (fake *FakeCommand) DestroyPipelineCallCount() int {
 	return len(fake.destroyPipelineArgsForCall)
 }
 
-func (fake *FakeCommand) DestroyPipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) DestroyPipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
 	fake.destroyPipelineMutex.Lock()
 	defer fake.destroyPipelineMutex.Unlock()
 	fake.DestroyPipelineStub = stub
 }
 
-func (fake *FakeCommand) DestroyPipelineArgsForCall(i int) string {
+func (fake *FakeCommand) DestroyPipelineArgsForCall(i int) concourse.PipelineRef {
 	fake.destroyPipelineMutex.RLock()
 	defer fake.destroyPipelineMutex.RUnlock()
 	argsForCall := fake.destroyPipelineArgsForCall[i]
@@ -172,21 +327,22 @@ func (fake *FakeCommand) DestroyPipelineReturnsOnCall(i int, result1 []byte, res
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) ExposePipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) ExposePipeline(arg1 concourse.PipelineRef) ([]byte, error) {
 	fake.exposePipelineMutex.Lock()
 	ret, specificReturn := fake.exposePipelineReturnsOnCall[len(fake.exposePipelineArgsForCall)]
 	fake.exposePipelineArgsForCall = append(fake.exposePipelineArgsForCall, struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}{arg1})
+	stub := fake.ExposePipelineStub
+	fakeReturns := fake.exposePipelineReturns
 	fake.recordInvocation("ExposePipeline", []interface{}{arg1})
 	fake.exposePipelineMutex.Unlock()
-	if fake.ExposePipelineStub != nil {
-		return fake.ExposePipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.exposePipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -196,13 +352,13 @@ func (fake *FakeCommand) ExposePipelineCallCount() int {
 	return len(fake.exposePipelineArgsForCall)
 }
 
-func (fake *FakeCommand) ExposePipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) ExposePipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
 	fake.exposePipelineMutex.Lock()
 	defer fake.exposePipelineMutex.Unlock()
 	fake.ExposePipelineStub = stub
 }
 
-func (fake *FakeCommand) ExposePipelineArgsForCall(i int) string {
+func (fake *FakeCommand) ExposePipelineArgsForCall(i int) concourse.PipelineRef {
 	fake.exposePipelineMutex.RLock()
 	defer fake.exposePipelineMutex.RUnlock()
 	argsForCall := fake.exposePipelineArgsForCall[i]
@@ -235,21 +391,22 @@ func (fake *FakeCommand) ExposePipelineReturnsOnCall(i int, result1 []byte, resu
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) GetPipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) GetPipeline(arg1 concourse.PipelineRef) ([]byte, error) {
 	fake.getPipelineMutex.Lock()
 	ret, specificReturn := fake.getPipelineReturnsOnCall[len(fake.getPipelineArgsForCall)]
 	fake.getPipelineArgsForCall = append(fake.getPipelineArgsForCall, struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}{arg1})
+	stub := fake.GetPipelineStub
+	fakeReturns := fake.getPipelineReturns
 	fake.recordInvocation("GetPipeline", []interface{}{arg1})
 	fake.getPipelineMutex.Unlock()
-	if fake.GetPipelineStub != nil {
-		return fake.GetPipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -259,13 +416,13 @@ func (fake *FakeCommand) GetPipelineCallCount() int {
 	return len(fake.getPipelineArgsForCall)
 }
 
-func (fake *FakeCommand) GetPipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) GetPipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
 	fake.getPipelineMutex.Lock()
 	defer fake.getPipelineMutex.Unlock()
 	fake.GetPipelineStub = stub
 }
 
-func (fake *FakeCommand) GetPipelineArgsForCall(i int) string {
+func (fake *FakeCommand) GetPipelineArgsForCall(i int) concourse.PipelineRef {
 	fake.getPipelineMutex.RLock()
 	defer fake.getPipelineMutex.RUnlock()
 	argsForCall := fake.getPipelineArgsForCall[i]
@@ -298,25 +455,88 @@ func (fake *FakeCommand) GetPipelineReturnsOnCall(i int, result1 []byte, result2
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) Login(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) ([]byte, error) {
+func (fake *FakeCommand) HidePipeline(arg1 concourse.PipelineRef) ([]byte, error) {
+	fake.hidePipelineMutex.Lock()
+	ret, specificReturn := fake.hidePipelineReturnsOnCall[len(fake.hidePipelineArgsForCall)]
+	fake.hidePipelineArgsForCall = append(fake.hidePipelineArgsForCall, struct {
+		arg1 concourse.PipelineRef
+	}{arg1})
+	stub := fake.HidePipelineStub
+	fakeReturns := fake.hidePipelineReturns
+	fake.recordInvocation("HidePipeline", []interface{}{arg1})
+	fake.hidePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) HidePipelineCallCount() int {
+	fake.hidePipelineMutex.RLock()
+	defer fake.hidePipelineMutex.RUnlock()
+	return len(fake.hidePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) HidePipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = stub
+}
+
+func (fake *FakeCommand) HidePipelineArgsForCall(i int) concourse.PipelineRef {
+	fake.hidePipelineMutex.RLock()
+	defer fake.hidePipelineMutex.RUnlock()
+	argsForCall := fake.hidePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) HidePipelineReturns(result1 []byte, result2 error) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = nil
+	fake.hidePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) HidePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = nil
+	if fake.hidePipelineReturnsOnCall == nil {
+		fake.hidePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.hidePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Login(arg1 string, arg2 concourse.Team, arg3 concourse.TLSConfig) ([]byte, error) {
 	fake.loginMutex.Lock()
 	ret, specificReturn := fake.loginReturnsOnCall[len(fake.loginArgsForCall)]
 	fake.loginArgsForCall = append(fake.loginArgsForCall, struct {
 		arg1 string
-		arg2 string
-		arg3 string
-		arg4 string
-		arg5 bool
-	}{arg1, arg2, arg3, arg4, arg5})
-	fake.recordInvocation("Login", []interface{}{arg1, arg2, arg3, arg4, arg5})
+		arg2 concourse.Team
+		arg3 concourse.TLSConfig
+	}{arg1, arg2, arg3})
+	stub := fake.LoginStub
+	fakeReturns := fake.loginReturns
+	fake.recordInvocation("Login", []interface{}{arg1, arg2, arg3})
 	fake.loginMutex.Unlock()
-	if fake.LoginStub != nil {
-		return fake.LoginStub(arg1, arg2, arg3, arg4, arg5)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.loginReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -326,17 +546,17 @@ func (fake *FakeCommand) LoginCallCount() int {
 	return len(fake.loginArgsForCall)
 }
 
-func (fake *FakeCommand) LoginCalls(stub func(string, string, string, string, bool) ([]byte, error)) {
+func (fake *FakeCommand) LoginCalls(stub func(string, concourse.Team, concourse.TLSConfig) ([]byte, error)) {
 	fake.loginMutex.Lock()
 	defer fake.loginMutex.Unlock()
 	fake.LoginStub = stub
 }
 
-func (fake *FakeCommand) LoginArgsForCall(i int) (string, string, string, string, bool) {
+func (fake *FakeCommand) LoginArgsForCall(i int) (string, concourse.Team, concourse.TLSConfig) {
 	fake.loginMutex.RLock()
 	defer fake.loginMutex.RUnlock()
 	argsForCall := fake.loginArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeCommand) LoginReturns(result1 []byte, result2 error) {
@@ -365,20 +585,154 @@ func (fake *FakeCommand) LoginReturnsOnCall(i int, result1 []byte, result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) Pipelines() ([]string, error) {
+func (fake *FakeCommand) OrderPipelines(arg1 []string) ([]byte, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.orderPipelinesMutex.Lock()
+	ret, specificReturn := fake.orderPipelinesReturnsOnCall[len(fake.orderPipelinesArgsForCall)]
+	fake.orderPipelinesArgsForCall = append(fake.orderPipelinesArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.OrderPipelinesStub
+	fakeReturns := fake.orderPipelinesReturns
+	fake.recordInvocation("OrderPipelines", []interface{}{arg1Copy})
+	fake.orderPipelinesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) OrderPipelinesCallCount() int {
+	fake.orderPipelinesMutex.RLock()
+	defer fake.orderPipelinesMutex.RUnlock()
+	return len(fake.orderPipelinesArgsForCall)
+}
+
+func (fake *FakeCommand) OrderPipelinesCalls(stub func([]string) ([]byte, error)) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = stub
+}
+
+func (fake *FakeCommand) OrderPipelinesArgsForCall(i int) []string {
+	fake.orderPipelinesMutex.RLock()
+	defer fake.orderPipelinesMutex.RUnlock()
+	argsForCall := fake.orderPipelinesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) OrderPipelinesReturns(result1 []byte, result2 error) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = nil
+	fake.orderPipelinesReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) OrderPipelinesReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = nil
+	if fake.orderPipelinesReturnsOnCall == nil {
+		fake.orderPipelinesReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.orderPipelinesReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) PausePipeline(arg1 concourse.PipelineRef) ([]byte, error) {
+	fake.pausePipelineMutex.Lock()
+	ret, specificReturn := fake.pausePipelineReturnsOnCall[len(fake.pausePipelineArgsForCall)]
+	fake.pausePipelineArgsForCall = append(fake.pausePipelineArgsForCall, struct {
+		arg1 concourse.PipelineRef
+	}{arg1})
+	stub := fake.PausePipelineStub
+	fakeReturns := fake.pausePipelineReturns
+	fake.recordInvocation("PausePipeline", []interface{}{arg1})
+	fake.pausePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) PausePipelineCallCount() int {
+	fake.pausePipelineMutex.RLock()
+	defer fake.pausePipelineMutex.RUnlock()
+	return len(fake.pausePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) PausePipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = stub
+}
+
+func (fake *FakeCommand) PausePipelineArgsForCall(i int) concourse.PipelineRef {
+	fake.pausePipelineMutex.RLock()
+	defer fake.pausePipelineMutex.RUnlock()
+	argsForCall := fake.pausePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) PausePipelineReturns(result1 []byte, result2 error) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = nil
+	fake.pausePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) PausePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = nil
+	if fake.pausePipelineReturnsOnCall == nil {
+		fake.pausePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.pausePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Pipelines() ([]concourse.PipelineRef, error) {
 	fake.pipelinesMutex.Lock()
 	ret, specificReturn := fake.pipelinesReturnsOnCall[len(fake.pipelinesArgsForCall)]
 	fake.pipelinesArgsForCall = append(fake.pipelinesArgsForCall, struct {
 	}{})
+	stub := fake.PipelinesStub
+	fakeReturns := fake.pipelinesReturns
 	fake.recordInvocation("Pipelines", []interface{}{})
 	fake.pipelinesMutex.Unlock()
-	if fake.PipelinesStub != nil {
-		return fake.PipelinesStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.pipelinesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -388,39 +742,39 @@ func (fake *FakeCommand) PipelinesCallCount() int {
 	return len(fake.pipelinesArgsForCall)
 }
 
-func (fake *FakeCommand) PipelinesCalls(stub func() ([]string, error)) {
+func (fake *FakeCommand) PipelinesCalls(stub func() ([]concourse.PipelineRef, error)) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = stub
 }
 
-func (fake *FakeCommand) PipelinesReturns(result1 []string, result2 error) {
+func (fake *FakeCommand) PipelinesReturns(result1 []concourse.PipelineRef, result2 error) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = nil
 	fake.pipelinesReturns = struct {
-		result1 []string
+		result1 []concourse.PipelineRef
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) PipelinesReturnsOnCall(i int, result1 []string, result2 error) {
+func (fake *FakeCommand) PipelinesReturnsOnCall(i int, result1 []concourse.PipelineRef, result2 error) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = nil
 	if fake.pipelinesReturnsOnCall == nil {
 		fake.pipelinesReturnsOnCall = make(map[int]struct {
-			result1 []string
+			result1 []concourse.PipelineRef
 			result2 error
 		})
 	}
 	fake.pipelinesReturnsOnCall[i] = struct {
-		result1 []string
+		result1 []concourse.PipelineRef
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) SetPipeline(arg1 string, arg2 string, arg3 []string, arg4 map[string]interface{}) ([]byte, error) {
+func (fake *FakeCommand) SetPipeline(arg1 concourse.PipelineRef, arg2 string, arg3 []string, arg4 map[string]interface{}) ([]byte, error) {
 	var arg3Copy []string
 	if arg3 != nil {
 		arg3Copy = make([]string, len(arg3))
@@ -429,20 +783,21 @@ func (fake *FakeCommand) SetPipeline(arg1 string, arg2 string, arg3 []string, ar
 	fake.setPipelineMutex.Lock()
 	ret, specificReturn := fake.setPipelineReturnsOnCall[len(fake.setPipelineArgsForCall)]
 	fake.setPipelineArgsForCall = append(fake.setPipelineArgsForCall, struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 		arg2 string
 		arg3 []string
 		arg4 map[string]interface{}
 	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.SetPipelineStub
+	fakeReturns := fake.setPipelineReturns
 	fake.recordInvocation("SetPipeline", []interface{}{arg1, arg2, arg3Copy, arg4})
 	fake.setPipelineMutex.Unlock()
-	if fake.SetPipelineStub != nil {
-		return fake.SetPipelineStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.setPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -452,13 +807,13 @@ func (fake *FakeCommand) SetPipelineCallCount() int {
 	return len(fake.setPipelineArgsForCall)
 }
 
-func (fake *FakeCommand) SetPipelineCalls(stub func(string, string, []string, map[string]interface{}) ([]byte, error)) {
+func (fake *FakeCommand) SetPipelineCalls(stub func(concourse.PipelineRef, string, []string, map[string]interface{}) ([]byte, error)) {
 	fake.setPipelineMutex.Lock()
 	defer fake.setPipelineMutex.Unlock()
 	fake.SetPipelineStub = stub
 }
 
-func (fake *FakeCommand) SetPipelineArgsForCall(i int) (string, string, []string, map[string]interface{}) {
+func (fake *FakeCommand) SetPipelineArgsForCall(i int) (concourse.PipelineRef, string, []string, map[string]interface{}) {
 	fake.setPipelineMutex.RLock()
 	defer fake.setPipelineMutex.RUnlock()
 	argsForCall := fake.setPipelineArgsForCall[i]
@@ -491,21 +846,142 @@ func (fake *FakeCommand) SetPipelineReturnsOnCall(i int, result1 []byte, result2
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) UnpausePipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) SetTeam(arg1 concourse.TeamConfig) ([]byte, error) {
+	fake.setTeamMutex.Lock()
+	ret, specificReturn := fake.setTeamReturnsOnCall[len(fake.setTeamArgsForCall)]
+	fake.setTeamArgsForCall = append(fake.setTeamArgsForCall, struct {
+		arg1 concourse.TeamConfig
+	}{arg1})
+	stub := fake.SetTeamStub
+	fakeReturns := fake.setTeamReturns
+	fake.recordInvocation("SetTeam", []interface{}{arg1})
+	fake.setTeamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) SetTeamCallCount() int {
+	fake.setTeamMutex.RLock()
+	defer fake.setTeamMutex.RUnlock()
+	return len(fake.setTeamArgsForCall)
+}
+
+func (fake *FakeCommand) SetTeamCalls(stub func(concourse.TeamConfig) ([]byte, error)) {
+	fake.setTeamMutex.Lock()
+	defer fake.setTeamMutex.Unlock()
+	fake.SetTeamStub = stub
+}
+
+func (fake *FakeCommand) SetTeamArgsForCall(i int) concourse.TeamConfig {
+	fake.setTeamMutex.RLock()
+	defer fake.setTeamMutex.RUnlock()
+	argsForCall := fake.setTeamArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) SetTeamReturns(result1 []byte, result2 error) {
+	fake.setTeamMutex.Lock()
+	defer fake.setTeamMutex.Unlock()
+	fake.SetTeamStub = nil
+	fake.setTeamReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) SetTeamReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.setTeamMutex.Lock()
+	defer fake.setTeamMutex.Unlock()
+	fake.SetTeamStub = nil
+	if fake.setTeamReturnsOnCall == nil {
+		fake.setTeamReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.setTeamReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Teams() ([]concourse.TeamConfig, error) {
+	fake.teamsMutex.Lock()
+	ret, specificReturn := fake.teamsReturnsOnCall[len(fake.teamsArgsForCall)]
+	fake.teamsArgsForCall = append(fake.teamsArgsForCall, struct {
+	}{})
+	stub := fake.TeamsStub
+	fakeReturns := fake.teamsReturns
+	fake.recordInvocation("Teams", []interface{}{})
+	fake.teamsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) TeamsCallCount() int {
+	fake.teamsMutex.RLock()
+	defer fake.teamsMutex.RUnlock()
+	return len(fake.teamsArgsForCall)
+}
+
+func (fake *FakeCommand) TeamsCalls(stub func() ([]concourse.TeamConfig, error)) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = stub
+}
+
+func (fake *FakeCommand) TeamsReturns(result1 []concourse.TeamConfig, result2 error) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = nil
+	fake.teamsReturns = struct {
+		result1 []concourse.TeamConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) TeamsReturnsOnCall(i int, result1 []concourse.TeamConfig, result2 error) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = nil
+	if fake.teamsReturnsOnCall == nil {
+		fake.teamsReturnsOnCall = make(map[int]struct {
+			result1 []concourse.TeamConfig
+			result2 error
+		})
+	}
+	fake.teamsReturnsOnCall[i] = struct {
+		result1 []concourse.TeamConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) UnpausePipeline(arg1 concourse.PipelineRef) ([]byte, error) {
 	fake.unpausePipelineMutex.Lock()
 	ret, specificReturn := fake.unpausePipelineReturnsOnCall[len(fake.unpausePipelineArgsForCall)]
 	fake.unpausePipelineArgsForCall = append(fake.unpausePipelineArgsForCall, struct {
-		arg1 string
+		arg1 concourse.PipelineRef
 	}{arg1})
+	stub := fake.UnpausePipelineStub
+	fakeReturns := fake.unpausePipelineReturns
 	fake.recordInvocation("UnpausePipeline", []interface{}{arg1})
 	fake.unpausePipelineMutex.Unlock()
-	if fake.UnpausePipelineStub != nil {
-		return fake.UnpausePipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.unpausePipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -515,13 +991,13 @@ func (fake *FakeCommand) UnpausePipelineCallCount() int {
 	return len(fake.unpausePipelineArgsForCall)
 }
 
-func (fake *FakeCommand) UnpausePipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) UnpausePipelineCalls(stub func(concourse.PipelineRef) ([]byte, error)) {
 	fake.unpausePipelineMutex.Lock()
 	defer fake.unpausePipelineMutex.Unlock()
 	fake.UnpausePipelineStub = stub
 }
 
-func (fake *FakeCommand) UnpausePipelineArgsForCall(i int) string {
+func (fake *FakeCommand) UnpausePipelineArgsForCall(i int) concourse.PipelineRef {
 	fake.unpausePipelineMutex.RLock()
 	defer fake.unpausePipelineMutex.RUnlock()
 	argsForCall := fake.unpausePipelineArgsForCall[i]
@@ -554,23 +1030,102 @@ func (fake *FakeCommand) UnpausePipelineReturnsOnCall(i int, result1 []byte, res
 	}{result1, result2}
 }
 
+func (fake *FakeCommand) ValidatePipeline(arg1 string, arg2 bool) ([]byte, error) {
+	fake.validatePipelineMutex.Lock()
+	ret, specificReturn := fake.validatePipelineReturnsOnCall[len(fake.validatePipelineArgsForCall)]
+	fake.validatePipelineArgsForCall = append(fake.validatePipelineArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.ValidatePipelineStub
+	fakeReturns := fake.validatePipelineReturns
+	fake.recordInvocation("ValidatePipeline", []interface{}{arg1, arg2})
+	fake.validatePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) ValidatePipelineCallCount() int {
+	fake.validatePipelineMutex.RLock()
+	defer fake.validatePipelineMutex.RUnlock()
+	return len(fake.validatePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) ValidatePipelineCalls(stub func(string, bool) ([]byte, error)) {
+	fake.validatePipelineMutex.Lock()
+	defer fake.validatePipelineMutex.Unlock()
+	fake.ValidatePipelineStub = stub
+}
+
+func (fake *FakeCommand) ValidatePipelineArgsForCall(i int) (string, bool) {
+	fake.validatePipelineMutex.RLock()
+	defer fake.validatePipelineMutex.RUnlock()
+	argsForCall := fake.validatePipelineArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCommand) ValidatePipelineReturns(result1 []byte, result2 error) {
+	fake.validatePipelineMutex.Lock()
+	defer fake.validatePipelineMutex.Unlock()
+	fake.ValidatePipelineStub = nil
+	fake.validatePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) ValidatePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.validatePipelineMutex.Lock()
+	defer fake.validatePipelineMutex.Unlock()
+	fake.ValidatePipelineStub = nil
+	if fake.validatePipelineReturnsOnCall == nil {
+		fake.validatePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.validatePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCommand) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.archivePipelineMutex.RLock()
+	defer fake.archivePipelineMutex.RUnlock()
 	fake.destroyPipelineMutex.RLock()
 	defer fake.destroyPipelineMutex.RUnlock()
 	fake.exposePipelineMutex.RLock()
 	defer fake.exposePipelineMutex.RUnlock()
 	fake.getPipelineMutex.RLock()
 	defer fake.getPipelineMutex.RUnlock()
+	fake.hidePipelineMutex.RLock()
+	defer fake.hidePipelineMutex.RUnlock()
 	fake.loginMutex.RLock()
 	defer fake.loginMutex.RUnlock()
+	fake.orderPipelinesMutex.RLock()
+	defer fake.orderPipelinesMutex.RUnlock()
+	fake.pausePipelineMutex.RLock()
+	defer fake.pausePipelineMutex.RUnlock()
 	fake.pipelinesMutex.RLock()
 	defer fake.pipelinesMutex.RUnlock()
 	fake.setPipelineMutex.RLock()
 	defer fake.setPipelineMutex.RUnlock()
+	fake.setTeamMutex.RLock()
+	defer fake.setTeamMutex.RUnlock()
+	fake.teamsMutex.RLock()
+	defer fake.teamsMutex.RUnlock()
 	fake.unpausePipelineMutex.RLock()
 	defer fake.unpausePipelineMutex.RUnlock()
+	fake.validatePipelineMutex.RLock()
+	defer fake.validatePipelineMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,124 @@
+package fly
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"gopkg.in/yaml.v2"
+)
+
+type flyrc struct {
+	Targets map[string]interface{} `yaml:"targets"`
+}
+
+type flyrcTarget struct {
+	API            string      `yaml:"api"`
+	Team           string      `yaml:"team"`
+	Insecure       bool        `yaml:"insecure,omitempty"`
+	CACert         string      `yaml:"ca_cert,omitempty"`
+	ClientCertPath string      `yaml:"client_cert_path,omitempty"`
+	ClientKeyPath  string      `yaml:"client_key_path,omitempty"`
+	Token          *flyrcToken `yaml:"token,omitempty"`
+}
+
+// certFiles are the paths of the certificates and keys passed to fly, which
+// are empty for those which are not provided.
+type certFiles struct {
+	caCert     string
+	clientCert string
+	clientKey  string
+}
+
+type flyrcToken struct {
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+}
+
+func flyrcPath(homeDir string) (string, error) {
+	if homeDir == "" {
+		var err error
+		homeDir, err = os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(homeDir, ".flyrc"), nil
+}
+
+// saveTarget writes the target to the flyrc as `fly login` would, allowing a
+// pre-issued token to be used without an interactive login. Other targets in
+// the flyrc are left untouched. An empty homeDir refers to the user's home
+// directory.
+func saveTarget(homeDir string, targetName string, target flyrcTarget) error {
+	path, err := flyrcPath(homeDir)
+	if err != nil {
+		return err
+	}
+
+	var rc flyrc
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = yaml.Unmarshal(contents, &rc)
+	if err != nil {
+		return err
+	}
+
+	if rc.Targets == nil {
+		rc.Targets = map[string]interface{}{}
+	}
+	rc.Targets[targetName] = target
+
+	contents, err = yaml.Marshal(rc)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, contents, 0600)
+}
+
+// writeCerts writes the certificates and key of tlsConfig next to the flyrc,
+// as fly only takes them as files.
+func (f *command) writeCerts(tlsConfig concourse.TLSConfig) (certFiles, error) {
+	var certs certFiles
+
+	path, err := flyrcPath(f.homeDir)
+	if err != nil {
+		return certs, err
+	}
+	prefix := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s-", url.PathEscape(f.target)))
+
+	write := func(name string, contents string) (string, error) {
+		if contents == "" {
+			return "", nil
+		}
+
+		certPath := prefix + name
+		return certPath, ioutil.WriteFile(certPath, []byte(contents), 0600)
+	}
+
+	certs.caCert, err = write("ca.pem", tlsConfig.CACert)
+	if err != nil {
+		return certs, err
+	}
+
+	certs.clientCert, err = write("client-cert.pem", tlsConfig.ClientCert)
+	if err != nil {
+		return certs, err
+	}
+
+	certs.clientKey, err = write("client-key.pem", tlsConfig.ClientKey)
+	if err != nil {
+		return certs, err
+	}
+
+	return certs, nil
+}
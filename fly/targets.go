@@ -0,0 +1,91 @@
+package fly
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/logger"
+)
+
+// CommandFactory returns the Command to use for the named team of the named
+// Concourse target, which is empty if there is only one. Each team gets its
+// own Command so that teams can be logged in to and used concurrently.
+type CommandFactory func(targetName string, teamName string) Command
+
+// Targets hands out a Command per target and team. Commands which shell out to
+// fly each use a fly target named after their target and team, with a private
+// home directory and so a private flyrc, so that they interfere neither with
+// each other nor with anything else using the real home directory.
+type Targets struct {
+	client        string
+	target        string
+	logger        logger.Logger
+	flyBinaryPath string
+	cliCache      *CLICache
+
+	dir string
+}
+
+// NewTargets creates the directory holding the home directory of each team.
+// target is used to name the fly targets of the unnamed target. fly is
+// downloaded from each target into cliCacheDir, unless it is empty, in which
+// case the fly at flyBinaryPath is always used.
+// Cleanup must be called to remove it once the Commands are no longer needed.
+func NewTargets(
+	client string,
+	target string,
+	logger logger.Logger,
+	flyBinaryPath string,
+	cliCacheDir string,
+) (*Targets, error) {
+	dir, err := ioutil.TempDir("", "concourse-pipeline-resource-fly")
+	if err != nil {
+		return nil, err
+	}
+
+	var cliCache *CLICache
+	if cliCacheDir != "" {
+		cliCache = NewCLICache(cliCacheDir, logger)
+	}
+
+	return &Targets{
+		client:        client,
+		target:        target,
+		logger:        logger,
+		flyBinaryPath: flyBinaryPath,
+		cliCache:      cliCache,
+		dir:           dir,
+	}, nil
+}
+
+// Command returns a new Command for the named team of the named target; it
+// is a CommandFactory.
+func (t *Targets) Command(targetName string, teamName string) Command {
+	if t.client == concourse.ClientAPI {
+		return NewAPICommand(t.logger, t.flyBinaryPath)
+	}
+
+	flyTarget, homeDir := t.target, t.dir
+	if targetName != "" {
+		flyTarget = targetName
+		homeDir = filepath.Join(t.dir, "targets", url.PathEscape(targetName))
+	}
+
+	return NewCommand(
+		fmt.Sprintf("%s-%s", flyTarget, teamName),
+		filepath.Join(homeDir, url.PathEscape(teamName)),
+		t.logger,
+		t.flyBinaryPath,
+		t.cliCache,
+	)
+}
+
+// Cleanup removes the home directories of all teams, along with their tokens.
+func (t *Targets) Cleanup() error {
+	t.logger.Debugf("Removing fly targets: %s\n", t.dir)
+	return os.RemoveAll(t.dir)
+}
@@ -0,0 +1,163 @@
+package fly_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/logger/loggerfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Targets", func() {
+	var (
+		tempDir       string
+		flyBinaryPath string
+		client        string
+
+		targets *fly.Targets
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		flyBinaryPath = filepath.Join(tempDir, "fake_fly")
+		err = ioutil.WriteFile(flyBinaryPath, []byte("#!/bin/sh\necho $HOME $@"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = concourse.ClientFly
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		targets, err = fly.NewTargets(
+			client,
+			"some-target",
+			&loggerfakes.FakeLogger{},
+			flyBinaryPath,
+			"",
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := targets.Cleanup()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	login := func(teamName string) fly.Command {
+		flyCommand := targets.Command("", teamName)
+
+		_, err := flyCommand.Login(
+			"some-url",
+			concourse.Team{Name: teamName, Token: "some-token"},
+			concourse.TLSConfig{},
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		return flyCommand
+	}
+
+	homeDir := func(flyCommand fly.Command) string {
+		output, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+		Expect(err).NotTo(HaveOccurred())
+
+		return strings.Fields(string(output))[0]
+	}
+
+	It("returns commands which use a fly target per team", func() {
+		output, err := login("team-1").GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(output)).To(ContainSubstring("-t some-target-team-1 get-pipeline"))
+	})
+
+	It("returns commands which use a private home directory per team", func() {
+		var (
+			wg    sync.WaitGroup
+			mutex sync.Mutex
+		)
+
+		homeDirs := map[string]string{}
+
+		for _, teamName := range []string{"team-1", "team-2", "team-3"} {
+			wg.Add(1)
+			go func(teamName string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				home := homeDir(login(teamName))
+
+				mutex.Lock()
+				homeDirs[teamName] = home
+				mutex.Unlock()
+			}(teamName)
+		}
+
+		wg.Wait()
+
+		Expect(homeDirs["team-1"]).NotTo(Equal(homeDirs["team-2"]))
+		Expect(homeDirs["team-2"]).NotTo(Equal(homeDirs["team-3"]))
+
+		realHome, err := os.UserHomeDir()
+		Expect(err).NotTo(HaveOccurred())
+
+		for teamName, home := range homeDirs {
+			Expect(home).NotTo(Equal(realHome))
+
+			flyrc, err := ioutil.ReadFile(filepath.Join(home, ".flyrc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(flyrc)).To(ContainSubstring("some-target-" + teamName + ":"))
+		}
+	})
+
+	It("returns commands which use a fly target and home directory per named target", func() {
+		flyCommand := targets.Command("prod", "team-1")
+
+		_, err := flyCommand.Login(
+			"some-url",
+			concourse.Team{Name: "team-1", Token: "some-token"},
+			concourse.TLSConfig{},
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		output, err := flyCommand.GetPipeline(concourse.PipelineRef{Name: "some-pipeline"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(ContainSubstring("-t prod-team-1 get-pipeline"))
+
+		Expect(homeDir(flyCommand)).NotTo(Equal(homeDir(login("team-1"))))
+	})
+
+	Describe("Cleanup", func() {
+		It("removes the home directories", func() {
+			home := homeDir(login("team-1"))
+
+			err := targets.Cleanup()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(home)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("when the api client is requested", func() {
+		BeforeEach(func() {
+			client = concourse.ClientAPI
+		})
+
+		It("returns commands which do not shell out to fly", func() {
+			_, err := targets.Command("", "team-1").Pipelines()
+			Expect(err).To(MatchError(ContainSubstring("login must be performed")))
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package fly
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
+	"gopkg.in/yaml.v2"
+)
+
+// DiscoverTeams logs in to the main team of source with flyCommand and
+// returns every team visible to it whose name matches include_teams and
+// exclude_teams. Teams which are also listed in source are returned as
+// listed; the others are logged in to with the main team's credentials,
+// without pipeline filters.
+func DiscoverTeams(flyCommand Command, source concourse.Source, tlsConfig concourse.TLSConfig) ([]concourse.Team, error) {
+	listed := make(map[string]concourse.Team)
+	for _, team := range source.Teams {
+		listed[team.Name] = team
+	}
+
+	mainTeam, found := listed[concourse.MainTeamName]
+	if !found {
+		return nil, fmt.Errorf("team (%s) configuration not found for discovering teams", concourse.MainTeamName)
+	}
+
+	teamFilter, err := filter.New(source.IncludeTeams, source.ExcludeTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = flyCommand.Login(source.Target, mainTeam, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := flyCommand.Teams()
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []concourse.Team
+	for _, t := range visible {
+		if !teamFilter.Match(t.Name) {
+			continue
+		}
+
+		team, found := listed[t.Name]
+		if !found {
+			team = mainTeam
+			team.Name = t.Name
+			team.IncludePipelines = nil
+			team.ExcludePipelines = nil
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+// teamConfigs parses teams as listed by Concourse, with their auth config.
+func teamConfigs(b []byte) ([]concourse.TeamConfig, error) {
+	var teams []struct {
+		Name string                        `json:"name"`
+		Auth map[string]concourse.TeamRole `json:"auth"`
+	}
+
+	err := json.Unmarshal(b, &teams)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]concourse.TeamConfig, len(teams))
+	for i, t := range teams {
+		configs[i] = concourse.TeamConfig{Name: t.Name, Roles: t.Auth}
+	}
+
+	return configs, nil
+}
+
+// flyTeamConfig returns the team's auth config in the form expected by
+// fly set-team --config, in which users and groups are keyed by connector.
+func flyTeamConfig(team concourse.TeamConfig) ([]byte, error) {
+	var roleNames []string
+	for roleName := range team.Roles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	roles := make([]map[string]interface{}, len(roleNames))
+	for i, roleName := range roleNames {
+		connectors := make(map[string]map[string][]string)
+		add := func(connector string, key string, value string) {
+			if connectors[connector] == nil {
+				connectors[connector] = make(map[string][]string)
+			}
+			connectors[connector][key] = append(connectors[connector][key], value)
+		}
+
+		for _, user := range team.Roles[roleName].Users {
+			connector, name := splitConnector(user)
+			add(connector, "users", name)
+		}
+
+		for _, group := range team.Roles[roleName].Groups {
+			connector, name := splitConnector(group)
+			add(connector, groupsKey(connector, name), name)
+		}
+
+		role := map[string]interface{}{"name": roleName}
+		for connector, values := range connectors {
+			role[connector] = values
+		}
+		roles[i] = role
+	}
+
+	return yaml.Marshal(map[string]interface{}{"roles": roles})
+}
+
+func splitConnector(s string) (string, string) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// groupsKey returns the key under which fly expects the group of the
+// connector, as not every connector calls its groups groups.
+func groupsKey(connector string, group string) string {
+	switch connector {
+	case "github":
+		if strings.Contains(group, ":") {
+			return "teams"
+		}
+		return "orgs"
+	case "cf":
+		if strings.Contains(group, ":") {
+			return "spaces"
+		}
+		return "orgs"
+	case "bitbucket-cloud":
+		return "teams"
+	}
+
+	return "groups"
+}
@@ -0,0 +1,135 @@
+package fly
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+const defaultTokenType = "Bearer"
+
+type token struct {
+	Type  string
+	Value string
+}
+
+func (t token) header() string {
+	return fmt.Sprintf("%s %s", t.Type, t.Value)
+}
+
+// teamToken returns the token to use for the team without any interactive
+// login, either as provided or by performing an OAuth client credentials
+// grant. A nil token is returned if the team uses neither.
+func teamToken(httpClient *http.Client, team concourse.Team) (*token, error) {
+	if team.Token != "" {
+		return &token{Type: defaultTokenType, Value: team.Token}, nil
+	}
+
+	if team.ClientID != "" {
+		return requestToken(
+			httpClient,
+			team.TokenURL,
+			url.Values{"grant_type": {"client_credentials"}},
+			team.ClientID,
+			team.ClientSecret,
+		)
+	}
+
+	return nil, nil
+}
+
+func requestToken(
+	httpClient *http.Client,
+	tokenURL string,
+	form url.Values,
+	clientID string,
+	clientSecret string,
+) (*token, error) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		tokenURL,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, unexpectedResponse(req, resp, body)
+	}
+
+	var t struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.Unmarshal(body, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.AccessToken == "" {
+		return nil, fmt.Errorf("no access token returned from %s", tokenURL)
+	}
+
+	tokenType := t.TokenType
+	if tokenType == "" {
+		tokenType = defaultTokenType
+	}
+
+	return &token{Type: tokenType, Value: t.AccessToken}, nil
+}
+
+// newHTTPClient returns a client which verifies the target with the system
+// CA certificates and the CA certificate of tlsConfig, if any, and presents
+// its client certificate, if any.
+func newHTTPClient(tlsConfig concourse.TLSConfig) (*http.Client, error) {
+	config := &tls.Config{InsecureSkipVerify: tlsConfig.Insecure}
+
+	if tlsConfig.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM([]byte(tlsConfig.CACert)) {
+			return nil, fmt.Errorf("%s contains no valid PEM certificate", "ca_cert")
+		}
+		config.RootCAs = pool
+	}
+
+	if tlsConfig.ClientCert != "" || tlsConfig.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(tlsConfig.ClientCert), []byte(tlsConfig.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s and %s: %s", "client_cert", "client_key", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: config,
+		},
+	}, nil
+}
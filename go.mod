@@ -6,6 +6,7 @@ require (
 	github.com/golang/protobuf v0.0.0-20160531231134-1111461c3593
 	github.com/onsi/ginkgo v1.2.1-0.20160509182050-5437a97bf824
 	github.com/onsi/gomega v0.0.0-20160516222431-c73e51675ad2
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/robdimsdale/sanitizer v0.0.0-20160522134901-ab2334cb7539
 	gopkg.in/check.v1 v1.0.0-20160105164936-4f90aeace3a2
 	gopkg.in/yaml.v2 v2.0.0-20160301204022-a83829b6f129
@@ -6,10 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
 )
 
 const (
@@ -18,18 +22,18 @@ const (
 
 type Command struct {
 	logger      logger.Logger
-	flyCommand  fly.Command
+	flyCommands fly.CommandFactory
 	downloadDir string
 }
 
 func NewCommand(
 	logger logger.Logger,
-	flyCommand fly.Command,
+	flyCommands fly.CommandFactory,
 	downloadDir string,
 ) *Command {
 	return &Command{
 		logger:      logger,
-		flyCommand:  flyCommand,
+		flyCommands: flyCommands,
 		downloadDir: downloadDir,
 	}
 }
@@ -37,76 +41,224 @@ func NewCommand(
 func (c *Command) Run(input concourse.InRequest) (concourse.InResponse, error) {
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
+	var downloaded []downloadedPipeline
+	for _, target := range input.Source.TargetSources() {
+		downloadDir := c.downloadDir
+		if target.Name != "" {
+			downloadDir = filepath.Join(c.downloadDir, target.Name)
+		}
+
+		targetPipelines, err := c.downloadTarget(target.Name, target.Source, input.Params, downloadDir)
+		downloaded = append(downloaded, targetPipelines...)
 		if err != nil {
+			if target.Name != "" {
+				return concourse.InResponse{}, fmt.Errorf("target '%s': %v", target.Name, err)
+			}
 			return concourse.InResponse{}, err
 		}
 	}
 
-	teams := make(map[string]concourse.Team)
+	pipelines := make([]concourse.PipelineMetadata, len(downloaded))
+	for i, d := range downloaded {
+		pipelines[i] = d.metadata
+	}
 
-	for _, team := range input.Source.Teams {
-		teams[team.Name] = team
+	if input.Params.Index {
+		err := writeIndex(filepath.Join(c.downloadDir, indexFileName), downloaded)
+		if err != nil {
+			return concourse.InResponse{}, err
+		}
 	}
 
-	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
-			insecure,
+	if input.Params.PipelinesFile != "" {
+		err := writePipelinesFile(
+			filepath.Join(c.downloadDir, input.Params.PipelinesFile),
+			input.Params.ConfigDir,
+			downloaded,
 		)
 		if err != nil {
 			return concourse.InResponse{}, err
 		}
+	}
+
+	// The config of each pipeline is only retained by Concourse as it is now,
+	// so the requested version cannot be fetched if the pipeline has changed
+	mismatches, err := versionMismatches(input, pipelines)
+	if err != nil {
+		return concourse.InResponse{}, err
+	}
+
+	if len(mismatches) > 0 {
+		mismatchErr := fmt.Errorf(
+			"pipeline configs do not match the requested version:\n%s",
+			strings.Join(mismatches, "\n"),
+		)
+		if input.Params.FailsOnVersionMismatch() {
+			return concourse.InResponse{}, mismatchErr
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", mismatchErr)
+	}
+
+	response := concourse.InResponse{
+		Version:  input.Version,
+		Metadata: append([]concourse.Metadata{}, concourse.PipelinesMetadata(pipelines)...),
+	}
 
-		c.logger.Debugf("Login successful\n")
+	return response, nil
+}
+
+// downloadTarget writes the config of each pipeline of a single target to
+// downloadDir, laid out and formatted as given by params, returning what is
+// to be reported about each.
+func (c *Command) downloadTarget(
+	targetName string,
+	source concourse.Source,
+	params concourse.InParams,
+	downloadDir string,
+) ([]downloadedPipeline, error) {
+	err := os.MkdirAll(downloadDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
 
-		pipelines, err := c.flyCommand.Pipelines()
+	tlsConfig, err := source.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	teams := source.Teams
+
+	if source.AllTeams != "" {
+		allTeams, err := strconv.ParseBool(source.AllTeams)
 		if err != nil {
-			return concourse.InResponse{}, err
+			return nil, err
 		}
-		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
 
-		for _, pipelineName := range pipelines {
-			outContents, err := c.flyCommand.GetPipeline(pipelineName)
+		if allTeams {
+			c.logger.Debugf("Discovering teams\n")
+			teams, err = fly.DiscoverTeams(c.flyCommands(targetName, concourse.MainTeamName), source, tlsConfig)
 			if err != nil {
-				return concourse.InResponse{}, err
+				return nil, err
 			}
-			pipelineContentsFilepath := filepath.Join(
-				c.downloadDir,
-				fmt.Sprintf(
-					"%s-%s.yml",
-					teamName,
-					pipelineName,
-				),
-			)
-			c.logger.Debugf(
-				"Writing pipeline contents to: %s\n",
-				pipelineContentsFilepath,
-			)
-			err = ioutil.WriteFile(pipelineContentsFilepath, outContents, os.ModePerm)
-			// Untested as it is too hard to force ioutil.WriteFile to error
-			if err != nil {
-				return concourse.InResponse{}, err
+			c.logger.Debugf("Discovered teams: %+v\n", teams)
+		}
+	}
+
+	flyCommands := make([]fly.Command, len(teams))
+	teamPipelines := make([][]concourse.PipelineRef, len(teams))
+
+	err = parallel.Run(len(teams), source.Concurrency, func(i int) error {
+		team := teams[i]
+		flyCommand := c.flyCommands(targetName, team.Name)
+
+		c.logger.Debugf("Performing login (%s)\n", team.Name)
+		_, err := flyCommand.Login(
+			source.Target,
+			team,
+			tlsConfig,
+		)
+		if err != nil {
+			return err
+		}
+
+		c.logger.Debugf("Login successful (%s)\n", team.Name)
+
+		pipelines, err := flyCommand.Pipelines()
+		if err != nil {
+			return err
+		}
+		c.logger.Debugf("Found pipelines (%s): %+v\n", team.Name, pipelines)
+
+		pipelineFilter, err := filter.New(team.IncludePipelines, team.ExcludePipelines)
+		if err != nil {
+			return err
+		}
+
+		for _, pipeline := range pipelines {
+			if !pipelineFilter.Match(pipeline.Name) {
+				c.logger.Debugf("Skipping filtered pipeline (%s): %s\n", team.Name, pipeline)
+				continue
 			}
+
+			teamPipelines[i] = append(teamPipelines[i], pipeline)
 		}
+
+		flyCommands[i] = flyCommand
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	response := concourse.InResponse{
-		Version:  input.Version,
-		Metadata: []concourse.Metadata{},
+	var downloads []pipelineDownload
+	for i, team := range teams {
+		for _, pipeline := range teamPipelines[i] {
+			downloads = append(downloads, pipelineDownload{
+				teamName:   team.Name,
+				pipeline:   pipeline,
+				flyCommand: flyCommands[i],
+			})
+		}
 	}
 
-	return response, nil
+	pipelines := make([]downloadedPipeline, len(downloads))
+
+	err = parallel.Run(len(downloads), source.Concurrency, func(i int) error {
+		download := downloads[i]
+
+		outContents, err := download.flyCommand.GetPipeline(download.pipeline)
+		if err != nil {
+			return err
+		}
+
+		version, err := pipelineconfig.Version(outContents)
+		if err != nil {
+			return err
+		}
+
+		if params.Format == concourse.FormatJSON {
+			outContents, err = pipelineconfig.JSON(outContents)
+			if err != nil {
+				return err
+			}
+		}
+
+		fileName := params.PipelineFileName(download.teamName, download.pipeline)
+		pipelines[i] = downloadedPipeline{
+			metadata: concourse.PipelineMetadata{
+				TargetName: targetName,
+				Target:     source.Target,
+				TeamName:   download.teamName,
+				Ref:        download.pipeline,
+				Version:    version,
+			},
+			fileName: filepath.Join(targetName, fileName),
+		}
+
+		pipelineContentsFilepath := filepath.Join(downloadDir, fileName)
+		c.logger.Debugf(
+			"Writing pipeline contents to: %s\n",
+			pipelineContentsFilepath,
+		)
+
+		err = os.MkdirAll(filepath.Dir(pipelineContentsFilepath), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		// Untested as it is too hard to force ioutil.WriteFile to error
+		return ioutil.WriteFile(pipelineContentsFilepath, outContents, os.ModePerm)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pipelines, nil
 }
 
-type pipelineWithContent struct {
-	name     string
-	contents []byte
+type pipelineDownload struct {
+	teamName   string
+	pipeline   concourse.PipelineRef
+	flyCommand fly.Command
 }
@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 
+	"github.com/concourse/concourse-pipeline-resource/cmd/out/filereader"
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/in"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+	"github.com/concourse/concourse-pipeline-resource/validator"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/robdimsdale/sanitizer"
@@ -29,6 +34,8 @@ var _ = Describe("In", func() {
 
 		fakeFlyCommand *flyfakes.FakeCommand
 
+		teamFakeFlyCommands map[string]*flyfakes.FakeCommand
+
 		pipelines        []string
 		pipelineVersions []string
 
@@ -39,6 +46,7 @@ var _ = Describe("In", func() {
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		teamFakeFlyCommands = map[string]*flyfakes.FakeCommand{}
 
 		var err error
 		downloadDir, err = ioutil.TempDir("", "")
@@ -76,10 +84,10 @@ pipeline2: foo
 			},
 		}
 
-		fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
-			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", name)
+		fakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", pipeline)
 
-			switch name {
+			switch pipeline.Name {
 			case pipelines[0]:
 				return []byte(pipelineContents[0]), nil
 			case pipelines[1]:
@@ -92,14 +100,19 @@ pipeline2: foo
 	})
 
 	JustBeforeEach(func() {
-		fakeFlyCommand.PipelinesReturns(pipelines, pipelinesErr)
+		fakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), pipelinesErr)
 
 		sanitized := concourse.SanitizedSource(inRequest.Source)
 		sanitizer := sanitizer.NewSanitizer(sanitized, GinkgoWriter)
 
 		ginkgoLogger = logger.NewLogger(sanitizer)
 
-		command = in.NewCommand(ginkgoLogger, fakeFlyCommand, downloadDir)
+		command = in.NewCommand(ginkgoLogger, func(targetName string, teamName string) fly.Command {
+			if teamFakeFlyCommand, ok := teamFakeFlyCommands[path.Join(targetName, teamName)]; ok {
+				return teamFakeFlyCommand
+			}
+			return fakeFlyCommand
+		}, downloadDir)
 	})
 
 	AfterEach(func() {
@@ -129,6 +142,287 @@ pipeline2: foo
 		Expect(string(contents)).To(Equal(pipelineContents[1]))
 	})
 
+	Context("when there are multiple teams", func() {
+		BeforeEach(func() {
+			inRequest.Source.Teams = append(inRequest.Source.Teams, concourse.Team{
+				Name: "other-team",
+			})
+			inRequest.Source.Concurrency = 2
+
+			for _, teamName := range []string{"main", "other-team"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				teamName := teamName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[teamName] = teamFakeFlyCommand
+			}
+		})
+
+		It("downloads the pipeline configs of each team using its own fly command", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, teamName := range []string{"main", "other-team"} {
+				for _, pipelineName := range pipelines {
+					contents, err := ioutil.ReadFile(filepath.Join(downloadDir, teamName+"-"+pipelineName+".yml"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(Equal(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipelineName)))
+				}
+			}
+		})
+	})
+
+	Context("when all teams are requested", func() {
+		BeforeEach(func() {
+			inRequest.Source.AllTeams = "true"
+			inRequest.Source.IncludeTeams = []string{"main", "team-*"}
+			inRequest.Source.Concurrency = 2
+
+			for _, teamName := range []string{"main", "team-a", "team-b"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				teamName := teamName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[teamName] = teamFakeFlyCommand
+			}
+
+			teamFakeFlyCommands["main"].TeamsReturns([]concourse.TeamConfig{
+				{Name: "main"},
+				{Name: "team-a"},
+				{Name: "team-b"},
+				{Name: "excluded-team"},
+			}, nil)
+		})
+
+		It("downloads the pipeline configs of every team visible to the main team", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := ioutil.ReadDir(downloadDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(6))
+
+			for _, teamName := range []string{"main", "team-a", "team-b"} {
+				for _, pipelineName := range pipelines {
+					contents, err := ioutil.ReadFile(filepath.Join(downloadDir, teamName+"-"+pipelineName+".yml"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(Equal(fmt.Sprintf("team: %s\npipeline: %s\n", teamName, pipelineName)))
+				}
+			}
+		})
+	})
+
+	Context("when multiple targets are provided", func() {
+		BeforeEach(func() {
+			inRequest.Source = concourse.Source{
+				Targets: []concourse.Target{
+					{
+						Name:   "prod",
+						Target: "prod target",
+						Teams:  teams,
+					},
+					{
+						Name:     "dr",
+						Target:   "dr target",
+						Insecure: "true",
+						Teams:    teams,
+					},
+				},
+			}
+
+			for _, targetName := range []string{"prod", "dr"} {
+				teamFakeFlyCommand := &flyfakes.FakeCommand{}
+				teamFakeFlyCommand.PipelinesReturns(pipelineRefs(pipelines...), nil)
+
+				targetName := targetName
+				teamFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+					return []byte(fmt.Sprintf("target: %s\npipeline: %s\n", targetName, pipeline)), nil
+				}
+
+				teamFakeFlyCommands[targetName+"/main"] = teamFakeFlyCommand
+			}
+		})
+
+		It("downloads the pipeline configs of each target into its own directory", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, targetName := range []string{"prod", "dr"} {
+				for _, pipelineName := range pipelines {
+					contents, err := ioutil.ReadFile(filepath.Join(downloadDir, targetName, "main-"+pipelineName+".yml"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(Equal(fmt.Sprintf("target: %s\npipeline: %s\n", targetName, pipelineName)))
+				}
+			}
+		})
+	})
+
+	Context("when a pipeline has several instances", func() {
+		BeforeEach(func() {
+			instancedFakeFlyCommand := &flyfakes.FakeCommand{}
+			instancedFakeFlyCommand.PipelinesReturns([]concourse.PipelineRef{
+				{Name: pipelines[0], InstanceVars: map[string]interface{}{"env": "dev"}},
+				{Name: pipelines[0], InstanceVars: map[string]interface{}{"env": "prod", "path": "a/b"}},
+			}, nil)
+			instancedFakeFlyCommand.GetPipelineStub = func(pipeline concourse.PipelineRef) ([]byte, error) {
+				return []byte(fmt.Sprintf("env: %s\n", pipeline.InstanceVars["env"])), nil
+			}
+
+			teamFakeFlyCommands["main"] = instancedFakeFlyCommand
+		})
+
+		It("downloads each instance to a file named after its instance vars", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main-"+pipelines[0]+"_env:dev.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("env: dev\n"))
+
+			contents, err = ioutil.ReadFile(filepath.Join(downloadDir, "main-"+pipelines[0]+`_env:prod,path:"a_b".yml`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("env: prod\n"))
+		})
+	})
+
+	Context("when pipeline filters are provided", func() {
+		BeforeEach(func() {
+			inRequest.Source.Teams[0].IncludePipelines = []string{"/-1$/"}
+		})
+
+		It("only downloads the matching pipelines", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(1))
+
+			files, err := ioutil.ReadDir(downloadDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name()).To(MatchRegexp("%s.yml", pipelines[0]))
+		})
+	})
+
+	Context("when the team layout is requested", func() {
+		BeforeEach(func() {
+			inRequest.Params.Layout = concourse.LayoutTeam
+		})
+
+		It("writes the configs into a directory per team", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main", "pipeline-1.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(pipelineContents[0]))
+
+			_, err = os.Stat(filepath.Join(downloadDir, "main", "pipeline-2.yml"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the json format is requested", func() {
+		BeforeEach(func() {
+			inRequest.Params.Format = concourse.FormatJSON
+		})
+
+		It("writes the configs as json", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main-pipeline-1.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{"pipeline1": "foo"}`))
+		})
+	})
+
+	Context("when an index is requested", func() {
+		BeforeEach(func() {
+			inRequest.Params.Index = true
+			inRequest.Params.Layout = concourse.LayoutTeam
+		})
+
+		It("writes an index of the pipelines", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			version1, err := pipelineconfig.Version([]byte(pipelineContents[0]))
+			Expect(err).NotTo(HaveOccurred())
+			version2, err := pipelineconfig.Version([]byte(pipelineContents[1]))
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "pipelines.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(fmt.Sprintf(`[
+				{"team": "main", "pipeline": "pipeline-1", "file": "main/pipeline-1.yml", "version": %q},
+				{"team": "main", "pipeline": "pipeline-2", "file": "main/pipeline-2.yml", "version": %q}
+			]`, version1, version2)))
+		})
+	})
+
+	Context("when a pipelines file is requested", func() {
+		BeforeEach(func() {
+			inRequest.Params.PipelinesFile = "pipelines.yml"
+			inRequest.Params.ConfigDir = "my-pipelines"
+		})
+
+		JustBeforeEach(func() {
+			fakeFlyCommand.PipelinesReturns([]concourse.PipelineRef{
+				{Name: pipelines[0], Paused: true, Public: true},
+				{Name: pipelines[1], InstanceVars: map[string]interface{}{"env": "prod"}},
+			}, nil)
+		})
+
+		It("writes a pipelines file which out sets the pipelines from", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			fromFile, err := filereader.PipelinesFromFile("pipelines.yml", downloadDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fromFile).To(Equal([]concourse.Pipeline{
+				{
+					Name:       pipelines[0],
+					ConfigFile: "my-pipelines/main-pipeline-1.yml",
+					TeamName:   "main",
+					State:      concourse.PipelineStatePaused,
+					Visibility: concourse.PipelineVisibilityExposed,
+				},
+				{
+					Name:         pipelines[1],
+					InstanceVars: map[string]interface{}{"env": "prod"},
+					ConfigFile:   "my-pipelines/main-pipeline-2_env:prod.yml",
+					TeamName:     "main",
+					State:        concourse.PipelineStateUnpaused,
+					Visibility:   concourse.PipelineVisibilityHidden,
+				},
+			}))
+
+			err = validator.ValidateOut(concourse.OutRequest{
+				Source: inRequest.Source,
+				Params: concourse.OutParams{Pipelines: fromFile},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("does not write an index by default", func() {
+		_, err := command.Run(inRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = os.Stat(filepath.Join(downloadDir, "pipelines.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
 	It("returns provided version", func() {
 		response, err := command.Run(inRequest)
 
@@ -145,6 +439,104 @@ pipeline2: foo
 		Expect(response.Metadata).NotTo(BeNil())
 	})
 
+	Context("when a config does not match the requested version", func() {
+		BeforeEach(func() {
+			inRequest.Version = concourse.Version{"main/" + pipelines[0]: pipelineVersions[0]}
+		})
+
+		It("warns, without error", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version).To(Equal(inRequest.Version))
+		})
+
+		Context("when on_version_mismatch is fail", func() {
+			BeforeEach(func() {
+				inRequest.Params.OnVersionMismatch = concourse.VersionMismatchFail
+			})
+
+			It("returns an error naming the pipeline", func() {
+				_, err := command.Run(inRequest)
+				Expect(err).To(MatchError(ContainSubstring(
+					"pipeline 'pipeline-1' in team 'main': requested 1234, got sha256:",
+				)))
+			})
+
+			Context("when the configs match the requested version", func() {
+				BeforeEach(func() {
+					version, err := pipelineconfig.Version([]byte(pipelineContents[0]))
+					Expect(err).NotTo(HaveOccurred())
+
+					inRequest.Version = concourse.Version{"main/" + pipelines[0]: version}
+				})
+
+				It("returns without error", func() {
+					_, err := command.Run(inRequest)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when legacy version keys are requested", func() {
+				BeforeEach(func() {
+					inRequest.Source.LegacyVersionKeys = "true"
+					inRequest.Version = concourse.Version{pipelines[0]: pipelineVersions[0]}
+				})
+
+				It("compares the version recorded under the pipeline name", func() {
+					_, err := command.Run(inRequest)
+					Expect(err).To(MatchError(ContainSubstring("pipeline 'pipeline-1'")))
+				})
+			})
+		})
+	})
+
+	It("returns metadata detailing each pipeline", func() {
+		fakeFlyCommand.PipelinesReturns([]concourse.PipelineRef{
+			{Name: pipelines[0], Paused: true},
+			{Name: pipelines[1], InstanceVars: map[string]interface{}{"env": "prod"}},
+		}, nil)
+
+		response, err := command.Run(inRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(response.Metadata).To(HaveLen(2))
+
+		Expect(response.Metadata[0].Name).To(Equal("main/pipeline-1"))
+		Expect(response.Metadata[0].Value).To(MatchRegexp(
+			`^paused: true, config: sha256:[0-9a-f]{64}, url: some target/teams/main/pipelines/pipeline-1$`,
+		))
+
+		Expect(response.Metadata[1].Name).To(Equal("main/pipeline-2/env:prod"))
+		Expect(response.Metadata[1].Value).To(HaveSuffix(
+			`url: some target/teams/main/pipelines/pipeline-2?vars.env=%22prod%22`,
+		))
+	})
+
+	Context("when there are more pipelines than are listed in metadata", func() {
+		BeforeEach(func() {
+			pipelines = make([]string, concourse.MaxPipelineMetadata+5)
+			for i := range pipelines {
+				pipelines[i] = fmt.Sprintf("pipeline-%d", i)
+			}
+
+			fakeFlyCommand.GetPipelineStub = func(concourse.PipelineRef) ([]byte, error) {
+				return []byte(pipelineContents[0]), nil
+			}
+		})
+
+		It("truncates the metadata with a count of the others", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(HaveLen(concourse.MaxPipelineMetadata + 1))
+			Expect(response.Metadata[concourse.MaxPipelineMetadata]).To(Equal(concourse.Metadata{
+				Name:  "more",
+				Value: "5 more pipelines not listed",
+			}))
+		})
+	})
+
 	Context("when insecure parses as true", func() {
 		BeforeEach(func() {
 			inRequest.Source.Insecure = "true"
@@ -155,9 +547,31 @@ pipeline2: foo
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
-			_, _, _, _, insecure := fakeFlyCommand.LoginArgsForCall(0)
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
 
-			Expect(insecure).To(BeTrue())
+			Expect(tlsConfig.Insecure).To(BeTrue())
+		})
+	})
+
+	Context("when a CA certificate and client certificate are provided", func() {
+		BeforeEach(func() {
+			inRequest.Source.CACert = "some-ca-cert"
+			inRequest.Source.ClientCert = "some-client-cert"
+			inRequest.Source.ClientKey = "some-client-key"
+		})
+
+		It("invokes the login with them", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
+
+			Expect(tlsConfig).To(Equal(concourse.TLSConfig{
+				CACert:     "some-ca-cert",
+				ClientCert: "some-client-cert",
+				ClientKey:  "some-client-key",
+			}))
 		})
 	})
 
@@ -219,3 +633,11 @@ pipeline2: foo
 		})
 	})
 })
+
+func pipelineRefs(names ...string) []concourse.PipelineRef {
+	refs := make([]concourse.PipelineRef, len(names))
+	for i, name := range names {
+		refs[i] = concourse.PipelineRef{Name: name}
+	}
+	return refs
+}
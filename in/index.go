@@ -0,0 +1,51 @@
+package in
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+const indexFileName = "pipelines.json"
+
+// downloadedPipeline is a pipeline whose config was written to fileName,
+// relative to the download directory.
+type downloadedPipeline struct {
+	metadata concourse.PipelineMetadata
+	fileName string
+}
+
+// indexEntry is what the index lists about each downloaded pipeline.
+type indexEntry struct {
+	Target       string                 `json:"target,omitempty"`
+	Team         string                 `json:"team"`
+	Pipeline     string                 `json:"pipeline"`
+	InstanceVars map[string]interface{} `json:"instance_vars,omitempty"`
+	File         string                 `json:"file"`
+	Version      string                 `json:"version"`
+}
+
+// writeIndex writes a JSON list of the downloaded pipelines to
+// indexFilepath.
+func writeIndex(indexFilepath string, downloaded []downloadedPipeline) error {
+	entries := make([]indexEntry, len(downloaded))
+	for i, d := range downloaded {
+		entries[i] = indexEntry{
+			Target:       d.metadata.TargetName,
+			Team:         d.metadata.TeamName,
+			Pipeline:     d.metadata.Ref.Name,
+			InstanceVars: d.metadata.Ref.InstanceVars,
+			File:         d.fileName,
+			Version:      d.metadata.Version,
+		}
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(indexFilepath, append(b, '\n'), os.ModePerm)
+}
@@ -0,0 +1,77 @@
+package in
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"gopkg.in/yaml.v2"
+)
+
+// pipelinesFile is the subset of concourse.OutParams which is read from the
+// pipelines_file of out.
+type pipelinesFile struct {
+	Pipelines []pipelinesFileEntry `yaml:"pipelines"`
+}
+
+// pipelinesFileEntry is a concourse.Pipeline without the fields which would
+// be written empty, as out rejects empty vars_files.
+type pipelinesFileEntry struct {
+	Name         string                 `yaml:"name"`
+	InstanceVars map[string]interface{} `yaml:"instance_vars,omitempty"`
+	ConfigFile   string                 `yaml:"config_file"`
+	TeamName     string                 `yaml:"team"`
+	State        string                 `yaml:"state"`
+	Visibility   string                 `yaml:"visibility"`
+	Targets      []string               `yaml:"targets,omitempty"`
+}
+
+// writePipelinesFile writes a pipelines_file for out which sets each of the
+// downloaded pipelines from its config and brings it to its current state
+// and visibility. Config files are referred to within configDir, which is
+// where out finds the downloaded configs.
+func writePipelinesFile(pipelinesFilepath string, configDir string, downloaded []downloadedPipeline) error {
+	file := pipelinesFile{
+		Pipelines: make([]pipelinesFileEntry, len(downloaded)),
+	}
+
+	for i, d := range downloaded {
+		ref := d.metadata.Ref
+
+		entry := pipelinesFileEntry{
+			Name:         ref.Name,
+			InstanceVars: ref.InstanceVars,
+			ConfigFile:   filepath.ToSlash(filepath.Join(configDir, d.fileName)),
+			TeamName:     d.metadata.TeamName,
+			State:        concourse.PipelineStateUnpaused,
+			Visibility:   concourse.PipelineVisibilityHidden,
+		}
+
+		if ref.Paused {
+			entry.State = concourse.PipelineStatePaused
+		}
+
+		if ref.Public {
+			entry.Visibility = concourse.PipelineVisibilityExposed
+		}
+
+		if d.metadata.TargetName != "" {
+			entry.Targets = []string{d.metadata.TargetName}
+		}
+
+		file.Pipelines[i] = entry
+	}
+
+	b, err := yaml.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(pipelinesFilepath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(pipelinesFilepath, b, os.ModePerm)
+}
@@ -0,0 +1,46 @@
+package in
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// versionMismatches describes each pipeline whose downloaded config does not
+// match the version requested for it. Pipelines absent from the requested
+// version, e.g. those created since, are not compared.
+func versionMismatches(input concourse.InRequest, pipelines []concourse.PipelineMetadata) ([]string, error) {
+	legacyVersionKeys := make(map[string]bool)
+	for _, target := range input.Source.TargetSources() {
+		if target.Source.LegacyVersionKeys == "" {
+			continue
+		}
+
+		legacy, err := strconv.ParseBool(target.Source.LegacyVersionKeys)
+		if err != nil {
+			return nil, err
+		}
+		legacyVersionKeys[target.Name] = legacy
+	}
+
+	var mismatches []string
+	for _, p := range pipelines {
+		key := concourse.TargetVersionKey(
+			p.TargetName,
+			concourse.PipelineVersionKey(p.TeamName, p.Ref.String(), legacyVersionKeys[p.TargetName]),
+		)
+
+		requested, found := input.Version[key]
+		if !found || requested == p.Version {
+			continue
+		}
+
+		mismatches = append(mismatches, fmt.Sprintf(
+			"pipeline '%s' in team '%s': requested %s, got %s",
+			p.Ref, p.TeamName, requested, p.Version,
+		))
+	}
+
+	return mismatches, nil
+}
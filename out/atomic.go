@@ -0,0 +1,109 @@
+package out
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+)
+
+// snapshotPipelines writes the current config of each existing pipeline to
+// dir, returning the path of each snapshot, which is empty for pipelines which
+// do not exist yet.
+func (c *Command) snapshotPipelines(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+	existing map[string]map[string]concourse.PipelineRef,
+	dir string,
+) ([]string, error) {
+	pipelines := input.Params.Pipelines
+	snapshotFilepaths := make([]string, len(pipelines))
+
+	err := parallel.Run(len(pipelines), input.Source.Concurrency, func(i int) error {
+		p := pipelines[i]
+		if _, exists := existing[p.TeamName][p.Ref().String()]; !exists {
+			return nil
+		}
+
+		c.logger.Debugf("Getting pipeline (%s): %s\n", p.TeamName, p.Ref())
+		config, err := flyCommands[p.TeamName].GetPipeline(p.Ref())
+		if err != nil {
+			return err
+		}
+
+		// A directory per team, as flat names can collide across teams
+		snapshotFilepath := filepath.Join(dir, concourse.TeamPipelineFileName(p.TeamName, p.Ref(), "yml"))
+		err = os.MkdirAll(filepath.Dir(snapshotFilepath), 0700)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(snapshotFilepath, config, 0600)
+		if err != nil {
+			return err
+		}
+
+		snapshotFilepaths[i] = snapshotFilepath
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshotFilepaths, nil
+}
+
+// rollbackPipelines restores the snapshot of each pipeline which was changed,
+// or destroys it if it did not exist before, and returns setErr along with
+// the outcome of the rollback.
+func (c *Command) rollbackPipelines(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+	snapshotFilepaths []string,
+	changed []bool,
+	setErr error,
+) error {
+	var outcomes []string
+	rolledBack := true
+
+	for i, p := range input.Params.Pipelines {
+		if !changed[i] {
+			continue
+		}
+
+		var (
+			output  []byte
+			err     error
+			outcome string
+		)
+
+		if snapshotFilepaths[i] != "" {
+			output, err = flyCommands[p.TeamName].SetPipeline(p.Ref(), snapshotFilepaths[i], nil, nil)
+			outcome = "restored"
+		} else {
+			output, err = flyCommands[p.TeamName].DestroyPipeline(p.Ref())
+			outcome = "destroyed"
+		}
+		c.logger.Debugf("pipeline '%s' rolled back; output:\n\n%s\n", p.Ref(), string(output))
+
+		if err != nil {
+			outcome = fmt.Sprintf("failed to roll back: %v", err)
+			rolledBack = false
+		}
+
+		outcomes = append(outcomes, fmt.Sprintf("pipeline '%s' in team '%s': %s", p.Ref(), p.TeamName, outcome))
+	}
+
+	fmt.Fprintf(os.Stderr, "rollback:\n%s\n", strings.Join(outcomes, "\n"))
+
+	if !rolledBack {
+		return fmt.Errorf("%v\nrollback failed:\n%s", setErr, strings.Join(outcomes, "\n"))
+	}
+
+	return fmt.Errorf("%v\nrolled back:\n%s", setErr, strings.Join(outcomes, "\n"))
+}
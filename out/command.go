@@ -1,15 +1,19 @@
 package out
 
 import (
-	"crypto/md5"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
 )
 
 const (
@@ -17,33 +21,101 @@ const (
 )
 
 type Command struct {
-	logger     logger.Logger
-	flyCommand fly.Command
-	sourcesDir string
+	logger      logger.Logger
+	flyCommands fly.CommandFactory
+	sourcesDir  string
 }
 
 func NewCommand(
 	logger logger.Logger,
-	flyCommand fly.Command,
+	flyCommands fly.CommandFactory,
 	sourcesDir string,
 ) *Command {
 	return &Command{
-		logger:     logger,
-		flyCommand: flyCommand,
-		sourcesDir: sourcesDir,
+		logger:      logger,
+		flyCommands: flyCommands,
+		sourcesDir:  sourcesDir,
 	}
 }
 
 func (c *Command) Run(input concourse.OutRequest) (concourse.OutResponse, error) {
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
+	var results []pipelineResult
+	response, err := c.runTargets(input, &results)
+	if err == nil {
+		response.Metadata = append(response.Metadata, pipelineMetadata(results)...)
+	}
+
+	reportFilepath := c.reportFilepath(input.Params)
+	if reportFilepath != "" {
+		c.logger.Debugf("Writing report to: %s\n", reportFilepath)
+		reportErr := writeReport(reportFilepath, results)
+		if reportErr != nil && err == nil {
+			return concourse.OutResponse{}, reportErr
+		}
+	}
+
+	return response, err
+}
+
+// runTargets sets the pipelines of each target, adding the result of each
+// pipeline to results.
+func (c *Command) runTargets(input concourse.OutRequest, results *[]pipelineResult) (concourse.OutResponse, error) {
+	targets := input.Source.TargetSources()
+	if len(targets) == 1 && targets[0].Name == "" {
+		return c.runTarget(targets[0].Name, input, results)
+	}
+
+	pipelineVersions := make(map[string]string)
+	summary := make([]string, len(targets))
+	var failed []string
+
+	for i, target := range targets {
+		targetInput := input.ForTarget(target)
+		if len(targetInput.Params.Pipelines) == 0 {
+			summary[i] = fmt.Sprintf("target '%s': skipped, as it has no pipelines", target.Name)
+			continue
+		}
+
+		c.logger.Debugf("Putting to target: %s\n", target.Name)
+		response, err := c.runTarget(target.Name, targetInput, results)
 		if err != nil {
-			return concourse.OutResponse{}, err
+			summary[i] = fmt.Sprintf("target '%s': failed: %v", target.Name, err)
+			failed = append(failed, target.Name)
+			continue
 		}
+
+		summary[i] = fmt.Sprintf("target '%s': succeeded", target.Name)
+		for key, version := range response.Version {
+			pipelineVersions[concourse.TargetVersionKey(target.Name, key)] = version
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%s\n", strings.Join(summary, "\n"))
+
+	if len(failed) > 0 {
+		return concourse.OutResponse{}, fmt.Errorf("failed to put to targets: %s", strings.Join(failed, ", "))
+	}
+
+	response := concourse.OutResponse{
+		Version:  pipelineVersions,
+		Metadata: []concourse.Metadata{},
+	}
+
+	return response, nil
+}
+
+// runTarget sets the pipelines of a single target, adding the result of each
+// pipeline to results.
+func (c *Command) runTarget(
+	targetName string,
+	input concourse.OutRequest,
+	results *[]pipelineResult,
+) (concourse.OutResponse, error) {
+	tlsConfig, err := input.Source.TLSConfig()
+	if err != nil {
+		return concourse.OutResponse{}, err
 	}
 
 	teams := make(map[string]concourse.Team)
@@ -52,96 +124,487 @@ func (c *Command) Run(input concourse.OutRequest) (concourse.OutResponse, error)
 		teams[team.Name] = team
 	}
 
+	legacyVersionKeys := false
+	if input.Source.LegacyVersionKeys != "" {
+		var err error
+		legacyVersionKeys, err = strconv.ParseBool(input.Source.LegacyVersionKeys)
+		if err != nil {
+			return concourse.OutResponse{}, err
+		}
+	}
+
 	pipelines := input.Params.Pipelines
 
 	c.logger.Debugf("Input pipelines: %+v\n", pipelines)
 
-	c.logger.Debugf("Setting pipelines\n")
+	var teamNames []string
 	for _, p := range pipelines {
-		team, found := teams[p.TeamName]
-		if !found {
+		if _, found := teams[p.TeamName]; !found {
 			return concourse.OutResponse{}, fmt.Errorf("team (%s) configuration not found for pipeline (%s)", p.TeamName, p.Name)
 		}
 
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			p.TeamName,
-			team.Username,
-			team.Password,
-			insecure,
-		)
+		if !stringContains(teamNames, p.TeamName) {
+			teamNames = append(teamNames, p.TeamName)
+		}
+	}
+
+	for _, teamName := range input.Params.Order.TeamNames() {
+		if _, found := teams[teamName]; !found {
+			return concourse.OutResponse{}, fmt.Errorf("team (%s) configuration not found for pipeline order", teamName)
+		}
+
+		if !stringContains(teamNames, teamName) {
+			teamNames = append(teamNames, teamName)
+		}
+	}
+
+	// Validation needs no login, so that it happens before anything, including
+	// the teams, is changed
+	validateCommands := make(map[string]fly.Command)
+	for _, teamName := range teamNames {
+		validateCommands[teamName] = c.flyCommands(targetName, teamName)
+	}
+
+	c.logger.Debugf("Validating pipelines\n")
+	err = c.validatePipelines(input, validateCommands)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+	c.logger.Debugf("Validating pipelines complete\n")
+
+	if len(input.Params.Teams) > 0 {
+		c.logger.Debugf("Setting teams\n")
+		err := c.setTeams(targetName, input, teams, tlsConfig)
 		if err != nil {
 			return concourse.OutResponse{}, err
 		}
+		c.logger.Debugf("Setting teams complete\n")
+	}
 
-		c.logger.Debugf("Login successful\n")
+	flyCommands, err := c.login(targetName, input, teams, teamNames, tlsConfig)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
 
-		configFilepath := filepath.Join(c.sourcesDir, p.ConfigFile)
+	if input.Params.DryRun {
+		return c.dryRun(input, flyCommands, legacyVersionKeys)
+	}
 
-		var varsFilepaths []string
-		for _, v := range p.VarsFiles {
-			varFilepath := filepath.Join(c.sourcesDir, v)
-			varsFilepaths = append(varsFilepaths, varFilepath)
+	existing, err := c.existingPipelines(flyCommands)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+
+	var snapshotFilepaths []string
+	if input.Params.Atomic {
+		snapshotDir, err := ioutil.TempDir("", "concourse-pipeline-resource-snapshots")
+		if err != nil {
+			return concourse.OutResponse{}, err
 		}
+		defer os.RemoveAll(snapshotDir)
 
-		var setOutput []byte
-		setOutput, err = c.flyCommand.SetPipeline(p.Name, configFilepath, varsFilepaths, p.Vars)
-		c.logger.Debugf("pipeline '%s' set; output:\n\n%s\n", p.Name, string(setOutput))
-		fmt.Fprintf(os.Stderr, "pipeline '%s' set; output:\n\n%s\n", p.Name, string(setOutput))
+		c.logger.Debugf("Snapshotting pipelines\n")
+		snapshotFilepaths, err = c.snapshotPipelines(input, flyCommands, existing, snapshotDir)
 		if err != nil {
 			return concourse.OutResponse{}, err
 		}
+	}
 
-		if p.Exposed {
-			_, err = c.flyCommand.ExposePipeline(p.Name)
-			if err != nil {
-				return concourse.OutResponse{}, err
+	targetResults := make([]pipelineResult, len(pipelines))
+	for i, p := range pipelines {
+		targetResults[i] = pipelineResult{
+			Target:   targetName,
+			Team:     p.TeamName,
+			Pipeline: p.Ref().String(),
+			Action:   actionNone,
+		}
+	}
+
+	c.logger.Debugf("Setting pipelines\n")
+	err = parallel.Run(len(pipelines), input.Source.Concurrency, func(i int) error {
+		p := pipelines[i]
+
+		_, exists := existing[p.TeamName][p.Ref().String()]
+
+		var err error
+		targetResults[i].Action, err = c.setPipeline(flyCommands[p.TeamName], p, exists)
+		if err != nil {
+			targetResults[i].Error = err.Error()
+			if input.Params.FailsFast() {
+				return err
 			}
 		}
 
-		if p.Unpaused {
-			_, err = c.flyCommand.UnpausePipeline(p.Name)
-			if err != nil {
-				return concourse.OutResponse{}, err
+		return nil
+	})
+	resultsOffset := len(*results)
+	*results = append(*results, targetResults...)
+	if err != nil {
+		if input.Params.Atomic {
+			changed := make([]bool, len(pipelines))
+			for i, result := range targetResults {
+				// Pipelines archived without a snapshot were already archived, as
+				// archived pipelines are not listed
+				changed[i] = result.Action == actionSet ||
+					(result.Action == actionArchived && snapshotFilepaths[i] != "")
 			}
+
+			return concourse.OutResponse{}, c.rollbackPipelines(input, flyCommands, snapshotFilepaths, changed, err)
 		}
+		return concourse.OutResponse{}, err
+	}
+
+	var failures []string
+	for _, result := range targetResults {
+		if result.Error != "" {
+			failures = append(failures, fmt.Sprintf("pipeline '%s' in team '%s': %s", result.Pipeline, result.Team, result.Error))
+		}
+	}
+
+	if len(failures) > 0 {
+		return concourse.OutResponse{}, fmt.Errorf("failed to set pipelines:\n%s", strings.Join(failures, "\n"))
 	}
 	c.logger.Debugf("Setting pipelines complete\n")
 
+	if input.Params.Prune {
+		err := c.prunePipelines(input, flyCommands)
+		if err != nil {
+			return concourse.OutResponse{}, err
+		}
+	}
+
+	err = c.orderPipelines(input, flyCommands, false)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+
+	versions := make([]string, len(pipelines))
+
+	err = parallel.Run(len(pipelines), input.Source.Concurrency, func(i int) error {
+		p := pipelines[i]
+
+		c.logger.Debugf("Getting pipeline (%s): %s\n", p.TeamName, p.Ref())
+		outBytes, err := flyCommands[p.TeamName].GetPipeline(p.Ref())
+		if err != nil {
+			return err
+		}
+
+		versions[i], err = pipelineconfig.Version(outBytes)
+		return err
+	})
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+
 	pipelineVersions := make(map[string]string)
+	for i, p := range pipelines {
+		key := concourse.PipelineVersionKey(p.TeamName, p.Ref().String(), legacyVersionKeys)
+		pipelineVersions[key] = versions[i]
 
-	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
+		current, exists := existing[p.TeamName][p.Ref().String()]
+		(*results)[resultsOffset+i].metadata = concourse.PipelineMetadata{
+			TargetName: targetName,
+			Target:     input.Source.Target,
+			TeamName:   p.TeamName,
+			Ref:        resultRef(p, current, exists),
+			Version:    versions[i],
+			Status:     resultStatus(targetResults[i].Action, exists),
+		}
+	}
+
+	response := concourse.OutResponse{
+		Version:  pipelineVersions,
+		Metadata: []concourse.Metadata{},
+	}
+
+	return response, nil
+}
+
+// login logs in to each of the named teams, each with its own fly command.
+func (c *Command) login(
+	targetName string,
+	input concourse.OutRequest,
+	teams map[string]concourse.Team,
+	teamNames []string,
+	tlsConfig concourse.TLSConfig,
+) (map[string]fly.Command, error) {
+	teamFlyCommands := make([]fly.Command, len(teamNames))
+
+	err := parallel.Run(len(teamNames), input.Source.Concurrency, func(i int) error {
+		teamName := teamNames[i]
+		flyCommand := c.flyCommands(targetName, teamName)
+
+		c.logger.Debugf("Performing login (%s)\n", teamName)
+		_, err := flyCommand.Login(
 			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
-			insecure,
+			teams[teamName],
+			tlsConfig,
 		)
+		if err != nil {
+			return err
+		}
+
+		c.logger.Debugf("Login successful (%s)\n", teamName)
+
+		teamFlyCommands[i] = flyCommand
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	flyCommands := make(map[string]fly.Command)
+	for i, teamName := range teamNames {
+		flyCommands[teamName] = teamFlyCommands[i]
+	}
+
+	return flyCommands, nil
+}
+
+// setPipeline sets the config of the pipeline, unless it is to be archived or
+// it exists with the same config, and then applies its state. It returns the
+// action which was taken, even if applying the state then failed.
+func (c *Command) setPipeline(flyCommand fly.Command, p concourse.Pipeline, exists bool) (string, error) {
+	// Setting an archived pipeline would unarchive it
+	if p.DesiredState() == concourse.PipelineStateArchived {
+		err := c.applyState(flyCommand, p)
+		if err != nil {
+			return actionNone, err
+		}
+
+		return actionArchived, nil
+	}
+
+	if exists {
+		unchanged, err := c.pipelineUnchanged(flyCommand, p)
+		if err != nil {
+			return actionNone, err
+		}
+
+		if unchanged {
+			fmt.Fprintf(os.Stderr, "pipeline '%s' is unchanged\n", p.Ref())
+			return actionUnchanged, c.applyState(flyCommand, p)
+		}
+	}
+
+	configFilepath, varsFilepaths := c.pipelineFilepaths(p)
+
+	setOutput, err := flyCommand.SetPipeline(p.Ref(), configFilepath, varsFilepaths, p.Vars)
+	c.logger.Debugf("pipeline '%s' set; output:\n\n%s\n", p.Ref(), string(setOutput))
+	fmt.Fprintf(os.Stderr, "pipeline '%s' set; output:\n\n%s\n", p.Ref(), string(setOutput))
+	if err != nil {
+		return actionNone, err
+	}
+
+	return actionSet, c.applyState(flyCommand, p)
+}
+
+// pipelineUnchanged returns true if the current config of the pipeline is the
+// same as its candidate config, ignoring formatting and key order.
+func (c *Command) pipelineUnchanged(flyCommand fly.Command, p concourse.Pipeline) (bool, error) {
+	configFilepath, varsFilepaths := c.pipelineFilepaths(p)
+
+	candidate, err := pipelineconfig.Render(configFilepath, varsFilepaths, p.Vars)
+	if err != nil {
+		return false, err
+	}
+
+	c.logger.Debugf("Getting pipeline (%s): %s\n", p.TeamName, p.Ref())
+	current, err := flyCommand.GetPipeline(p.Ref())
+	if err != nil {
+		return false, err
+	}
+
+	candidateVersion, err := pipelineconfig.Version(candidate)
+	if err != nil {
+		return false, err
+	}
+
+	currentVersion, err := pipelineconfig.Version(current)
+	if err != nil {
+		return false, err
+	}
+
+	return candidateVersion == currentVersion, nil
+}
+
+// existingPipelines returns the pipelines of each team, keyed by team name
+// and then by pipeline ref.
+func (c *Command) existingPipelines(
+	flyCommands map[string]fly.Command,
+) (map[string]map[string]concourse.PipelineRef, error) {
+	existing := make(map[string]map[string]concourse.PipelineRef)
+	for teamName, flyCommand := range flyCommands {
+		refs, err := flyCommand.Pipelines()
+		if err != nil {
+			return nil, err
+		}
+		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, refs)
+
+		existing[teamName] = make(map[string]concourse.PipelineRef)
+		for _, ref := range refs {
+			existing[teamName][ref.String()] = ref
+		}
+	}
+
+	return existing, nil
+}
+
+// applyState brings the pipeline to its desired visibility and then to its
+// desired state, leaving either as is if it is not provided.
+func (c *Command) applyState(flyCommand fly.Command, p concourse.Pipeline) error {
+	var (
+		output []byte
+		err    error
+	)
+
+	switch p.DesiredVisibility() {
+	case concourse.PipelineVisibilityExposed:
+		output, err = flyCommand.ExposePipeline(p.Ref())
+	case concourse.PipelineVisibilityHidden:
+		output, err = flyCommand.HidePipeline(p.Ref())
+	}
+	c.logger.Debugf("pipeline '%s' visibility applied; output:\n\n%s\n", p.Ref(), string(output))
+	if err != nil {
+		return err
+	}
+
+	output = nil
+	switch p.DesiredState() {
+	case concourse.PipelineStatePaused:
+		output, err = flyCommand.PausePipeline(p.Ref())
+	case concourse.PipelineStateUnpaused:
+		output, err = flyCommand.UnpausePipeline(p.Ref())
+	case concourse.PipelineStateArchived:
+		output, err = flyCommand.ArchivePipeline(p.Ref())
+		fmt.Fprintf(os.Stderr, "pipeline '%s' archived; output:\n\n%s\n", p.Ref(), string(output))
+	}
+	c.logger.Debugf("pipeline '%s' state applied; output:\n\n%s\n", p.Ref(), string(output))
+
+	return err
+}
+
+func (c *Command) pipelineFilepaths(p concourse.Pipeline) (string, []string) {
+	configFilepath := filepath.Join(c.sourcesDir, p.ConfigFile)
+
+	var varsFilepaths []string
+	for _, v := range p.VarsFiles {
+		varFilepath := filepath.Join(c.sourcesDir, v)
+		varsFilepaths = append(varsFilepaths, varFilepath)
+	}
+
+	return configFilepath, varsFilepaths
+}
+
+// dryRun writes a diff between the current and candidate config of each
+// pipeline to stderr (and to diff_dir, if provided) without changing anything.
+// The returned version reflects the current configs.
+func (c *Command) dryRun(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+	legacyVersionKeys bool,
+) (concourse.OutResponse, error) {
+	var diffDir string
+	if input.Params.DiffDir != "" {
+		diffDir = filepath.Join(c.sourcesDir, input.Params.DiffDir)
+		err := os.MkdirAll(diffDir, os.ModePerm)
 		if err != nil {
 			return concourse.OutResponse{}, err
 		}
+	}
 
-		c.logger.Debugf("Login successful\n")
+	existing, err := c.existingPipelines(flyCommands)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
 
-		for _, pipeline := range pipelines {
-			if pipeline.TeamName != teamName {
-				continue
+	pipelines := input.Params.Pipelines
+	versions := make([]string, len(pipelines))
+
+	c.logger.Debugf("Diffing pipelines\n")
+	err = parallel.Run(len(pipelines), input.Source.Concurrency, func(i int) error {
+		p := pipelines[i]
+		flyCommand := flyCommands[p.TeamName]
+
+		var current []byte
+		if _, exists := existing[p.TeamName][p.Ref().String()]; exists {
+			var err error
+			c.logger.Debugf("Getting pipeline (%s): %s\n", p.TeamName, p.Ref())
+			current, err = flyCommand.GetPipeline(p.Ref())
+			if err != nil {
+				return err
 			}
-			c.logger.Debugf("Getting pipeline: %s\n", pipeline.Name)
-			outBytes, err := c.flyCommand.GetPipeline(pipeline.Name)
+
+			versions[i], err = pipelineconfig.Version(current)
+			if err != nil {
+				return err
+			}
+		}
+
+		configFilepath, varsFilepaths := c.pipelineFilepaths(p)
+
+		candidate, err := pipelineconfig.Render(configFilepath, varsFilepaths, p.Vars)
+		if err != nil {
+			return err
+		}
+
+		diff, err := pipelineconfig.Diff(
+			current,
+			candidate,
+			fmt.Sprintf("current/%s/%s", p.TeamName, p.Ref()),
+			fmt.Sprintf("candidate/%s/%s", p.TeamName, p.Ref()),
+		)
+		if err != nil {
+			return err
+		}
+
+		if diff == "" {
+			fmt.Fprintf(os.Stderr, "pipeline '%s' in team '%s' is unchanged (dry run)\n", p.Ref(), p.TeamName)
+		} else {
+			fmt.Fprintf(os.Stderr, "pipeline '%s' in team '%s' would be changed (dry run):\n\n%s\n", p.Ref(), p.TeamName, diff)
+		}
+
+		if diffDir != "" {
+			diffFilepath := filepath.Join(diffDir, concourse.TeamPipelineFileName(p.TeamName, p.Ref(), "diff"))
+			c.logger.Debugf("Writing diff to: %s\n", diffFilepath)
+			err := os.MkdirAll(filepath.Dir(diffFilepath), os.ModePerm)
 			if err != nil {
-				return concourse.OutResponse{}, err
+				return err
 			}
 
-			version := fmt.Sprintf(
-				"%x",
-				md5.Sum(outBytes),
-			)
-			pipelineVersions[pipeline.Name] = version
+			// Untested as it is too hard to force ioutil.WriteFile to error
+			return ioutil.WriteFile(diffFilepath, []byte(diff), os.ModePerm)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+	c.logger.Debugf("Diffing pipelines complete\n")
+
+	if input.Params.Prune {
+		input.Params.PruneDryRun = true
+		err := c.prunePipelines(input, flyCommands)
+		if err != nil {
+			return concourse.OutResponse{}, err
+		}
+	}
+
+	err = c.orderPipelines(input, flyCommands, true)
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+
+	pipelineVersions := make(map[string]string)
+	for i, p := range pipelines {
+		if versions[i] == "" {
+			continue
+		}
+
+		key := concourse.PipelineVersionKey(p.TeamName, p.Ref().String(), legacyVersionKeys)
+		pipelineVersions[key] = versions[i]
 	}
 
 	response := concourse.OutResponse{
@@ -151,3 +614,130 @@ func (c *Command) Run(input concourse.OutRequest) (concourse.OutResponse, error)
 
 	return response, nil
 }
+
+// prunePipelines destroys any pipeline which is not declared in the params,
+// for each team which has at least one declared pipeline.
+func (c *Command) prunePipelines(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+) error {
+	protected, err := filter.New(input.Params.ProtectedPipelines, nil)
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]map[string]bool)
+	var teamNames []string
+	for _, p := range input.Params.Pipelines {
+		if declared[p.TeamName] == nil {
+			declared[p.TeamName] = make(map[string]bool)
+			teamNames = append(teamNames, p.TeamName)
+		}
+		declared[p.TeamName][p.Ref().String()] = true
+	}
+
+	c.logger.Debugf("Pruning pipelines\n")
+	err = parallel.Run(len(teamNames), input.Source.Concurrency, func(i int) error {
+		teamName := teamNames[i]
+		flyCommand := flyCommands[teamName]
+
+		pipelines, err := flyCommand.Pipelines()
+		if err != nil {
+			return err
+		}
+		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
+
+		for _, pipeline := range pipelines {
+			if declared[teamName][pipeline.String()] {
+				continue
+			}
+
+			if len(input.Params.ProtectedPipelines) > 0 && protected.Match(pipeline.Name) {
+				c.logger.Debugf("Not pruning protected pipeline (%s): %s\n", teamName, pipeline)
+				continue
+			}
+
+			if input.Params.PruneDryRun {
+				fmt.Fprintf(os.Stderr, "pipeline '%s' in team '%s' would be destroyed (dry run)\n", pipeline, teamName)
+				continue
+			}
+
+			destroyOutput, err := flyCommand.DestroyPipeline(pipeline)
+			c.logger.Debugf("pipeline '%s' destroyed; output:\n\n%s\n", pipeline, string(destroyOutput))
+			fmt.Fprintf(os.Stderr, "pipeline '%s' in team '%s' destroyed; output:\n\n%s\n", pipeline, teamName, string(destroyOutput))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	c.logger.Debugf("Pruning pipelines complete\n")
+
+	return nil
+}
+
+// orderPipelines orders the pipelines of each team as requested by the order
+// param, either explicitly or as they are declared. Teams are only reported,
+// rather than ordered, if dryRun is true.
+func (c *Command) orderPipelines(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+	dryRun bool,
+) error {
+	order := input.Params.Order
+	if order.IsZero() {
+		return nil
+	}
+
+	pipelineNames := order.Teams
+	teamNames := order.TeamNames()
+
+	if pipelineNames == nil {
+		pipelineNames = make(map[string][]string)
+		for _, p := range input.Params.Pipelines {
+			if _, found := pipelineNames[p.TeamName]; !found {
+				teamNames = append(teamNames, p.TeamName)
+			}
+
+			// Instances of a pipeline are ordered together, by name
+			if !stringContains(pipelineNames[p.TeamName], p.Name) {
+				pipelineNames[p.TeamName] = append(pipelineNames[p.TeamName], p.Name)
+			}
+		}
+	}
+
+	c.logger.Debugf("Ordering pipelines\n")
+	err := parallel.Run(len(teamNames), input.Source.Concurrency, func(i int) error {
+		teamName := teamNames[i]
+
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "pipelines in team '%s' would be ordered (dry run): %v\n", teamName, pipelineNames[teamName])
+			return nil
+		}
+
+		orderOutput, err := flyCommands[teamName].OrderPipelines(pipelineNames[teamName])
+		c.logger.Debugf("pipelines in team '%s' ordered; output:\n\n%s\n", teamName, string(orderOutput))
+		fmt.Fprintf(os.Stderr, "pipelines in team '%s' ordered: %v\n", teamName, pipelineNames[teamName])
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.logger.Debugf("Ordering pipelines complete\n")
+
+	return nil
+}
+
+func stringContains(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
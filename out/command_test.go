@@ -1,12 +1,16 @@
 package out_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 	"github.com/concourse/concourse-pipeline-resource/out"
@@ -39,16 +43,34 @@ var _ = Describe("Out", func() {
 		badOutRequest concourse.OutRequest
 		command       *out.Command
 
-		fakeFlyCommand *flyfakes.FakeCommand
+		fakeFlyCommand      *flyfakes.FakeCommand
+		otherFakeFlyCommand *flyfakes.FakeCommand
+
+		targetFakeFlyCommands map[string]*flyfakes.FakeCommand
 	)
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		otherFakeFlyCommand = &flyfakes.FakeCommand{}
+		targetFakeFlyCommands = map[string]*flyfakes.FakeCommand{}
 
 		var err error
 		sourcesDir, err = ioutil.TempDir("", "")
 		Expect(err).NotTo(HaveOccurred())
 
+		files := map[string]string{
+			"pipeline_1.yml": "pipeline1: ((value))\n",
+			"vars_1.yml":     "value: bar\n",
+			"vars_2.yml":     "value: foo\n",
+			"pipeline_2.yml": "pipeline2: bar\n",
+			"pipeline_3.yml": "pipeline3: foo\n",
+		}
+
+		for name, contents := range files {
+			err := ioutil.WriteFile(filepath.Join(sourcesDir, name), []byte(contents), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
 		target = "some target"
 		username = "some user"
 		otherUsername = "some other user"
@@ -101,11 +123,11 @@ pipeline3: foo
 			},
 		}
 
-		fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+		getPipelineStub := func(pipeline concourse.PipelineRef) ([]byte, error) {
 			defer GinkgoRecover()
-			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", name)
+			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", pipeline)
 
-			switch name {
+			switch pipeline.Name {
 			case apiPipelines[0]:
 				return []byte(pipelineContents[0]), nil
 			case apiPipelines[1]:
@@ -117,6 +139,8 @@ pipeline3: foo
 				return nil, nil
 			}
 		}
+		fakeFlyCommand.GetPipelineStub = getPipelineStub
+		otherFakeFlyCommand.GetPipelineStub = getPipelineStub
 
 		outRequest = concourse.OutRequest{
 			Source: concourse.Source{
@@ -158,13 +182,28 @@ pipeline3: foo
 
 	JustBeforeEach(func() {
 		fakeFlyCommand.SetPipelineReturns(nil, setPipelinesErr)
+		otherFakeFlyCommand.SetPipelineReturns(nil, setPipelinesErr)
 
 		sanitized := concourse.SanitizedSource(outRequest.Source)
 		sanitizer := sanitizer.NewSanitizer(sanitized, GinkgoWriter)
 
 		ginkgoLogger = logger.NewLogger(sanitizer)
 
-		command = out.NewCommand(ginkgoLogger, fakeFlyCommand, sourcesDir)
+		command = out.NewCommand(ginkgoLogger, func(targetName string, name string) fly.Command {
+			if targetFakeFlyCommand, ok := targetFakeFlyCommands[targetName]; ok {
+				return targetFakeFlyCommand
+			}
+
+			switch name {
+			case teamName:
+				return fakeFlyCommand
+			case otherTeamName:
+				return otherFakeFlyCommand
+			default:
+				Fail("Unexpected team: " + name)
+				return nil
+			}
+		}, sourcesDir)
 	})
 
 	AfterEach(func() {
@@ -172,17 +211,138 @@ pipeline3: foo
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	Describe("validation", func() {
+		var (
+			validatedConfigs map[string]string
+			validationMutex  sync.Mutex
+		)
+
+		BeforeEach(func() {
+			validatedConfigs = map[string]string{}
+
+			validateStub := func(configFilepath string, strict bool) ([]byte, error) {
+				contents, err := ioutil.ReadFile(configFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				validationMutex.Lock()
+				defer validationMutex.Unlock()
+				validatedConfigs[string(contents)] = configFilepath
+
+				if strings.Contains(string(contents), "invalid") {
+					return nil, fmt.Errorf("some validation error")
+				}
+
+				return []byte("looks good\n"), nil
+			}
+			fakeFlyCommand.ValidatePipelineStub = validateStub
+			otherFakeFlyCommand.ValidatePipelineStub = validateStub
+		})
+
+		It("validates the interpolated config of each pipeline", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validatedConfigs).To(HaveLen(3))
+			Expect(validatedConfigs).To(HaveKey("pipeline1: foo\n"))
+			Expect(validatedConfigs).To(HaveKey("pipeline2: bar\n"))
+			Expect(validatedConfigs).To(HaveKey("pipeline3: foo\n"))
+
+			Expect(fakeFlyCommand.ValidatePipelineCallCount()).To(Equal(2))
+			Expect(otherFakeFlyCommand.ValidatePipelineCallCount()).To(Equal(1))
+
+			_, strict := fakeFlyCommand.ValidatePipelineArgsForCall(0)
+			Expect(strict).To(BeFalse())
+		})
+
+		Context("when strict validation is requested", func() {
+			BeforeEach(func() {
+				outRequest.Params.StrictValidation = true
+			})
+
+			It("validates strictly", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, strict := fakeFlyCommand.ValidatePipelineArgsForCall(0)
+				Expect(strict).To(BeTrue())
+			})
+		})
+
+		Context("when several configs are invalid", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(filepath.Join(sourcesDir, "pipeline_1.yml"), []byte("invalid: true\n"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(sourcesDir, "pipeline_3.yml"), []byte("[invalid"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports all the errors without setting any pipeline", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(HavePrefix("invalid pipeline configs:\n"))
+				Expect(err.Error()).To(ContainSubstring("pipeline 'pipeline-1' in team 'main': some validation error"))
+				Expect(err.Error()).To(ContainSubstring("pipeline 'pipeline-3' in team 'some-other-team': failed to parse config file"))
+
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+				Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			})
+
+			Context("when teams are also to be set", func() {
+				BeforeEach(func() {
+					outRequest.Params.Teams = []concourse.TeamConfig{
+						{
+							Name: "new-team",
+							Roles: map[string]concourse.TeamRole{
+								"owner": {Users: []string{"local:admin"}},
+							},
+						},
+					}
+				})
+
+				It("does not set any team or log in", func() {
+					_, err := command.Run(outRequest)
+					Expect(err).To(HaveOccurred())
+
+					Expect(fakeFlyCommand.SetTeamCallCount()).To(Equal(0))
+					Expect(fakeFlyCommand.LoginCallCount()).To(Equal(0))
+					Expect(otherFakeFlyCommand.LoginCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a pipeline is to be archived", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].State = concourse.PipelineStateArchived
+			})
+
+			It("does not validate its config", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.ValidatePipelineCallCount()).To(Equal(1))
+			})
+		})
+	})
+
 	It("invokes fly set-pipeline for each pipeline", func() {
 		_, err := command.Run(outRequest)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(len(pipelines)))
+		Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+		Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(1))
 
 		for i, p := range pipelines {
-			name, configFilepath, varsFilepaths, vars := fakeFlyCommand.SetPipelineArgsForCall(i)
-			_, tname, _, _, _ := fakeFlyCommand.LoginArgsForCall(i)
-			Expect(name).To(Equal(p.Name))
-			Expect(tname).To(Equal(p.TeamName))
+			teamFakeFlyCommand, call := fakeFlyCommand, i
+			if p.TeamName == otherTeamName {
+				teamFakeFlyCommand, call = otherFakeFlyCommand, 0
+			}
+
+			ref, configFilepath, varsFilepaths, vars := teamFakeFlyCommand.SetPipelineArgsForCall(call)
+			_, team, _ := teamFakeFlyCommand.LoginArgsForCall(0)
+			Expect(ref).To(Equal(p.Ref()))
+			Expect(team.Name).To(Equal(p.TeamName))
 			Expect(configFilepath).To(Equal(filepath.Join(sourcesDir, p.ConfigFile)))
 
 			// the first pipeline has vars files
@@ -193,8 +353,8 @@ pipeline3: foo
 
 			// the second pipeline has Unpaused and Exposed set to true
 			if i == 1 {
-				name := fakeFlyCommand.UnpausePipelineArgsForCall(0)
-				Expect(name).To(Equal(p.Name))
+				ref := fakeFlyCommand.UnpausePipelineArgsForCall(0)
+				Expect(ref).To(Equal(p.Ref()))
 				Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(1))
 				Expect(fakeFlyCommand.ExposePipelineCallCount()).To(Equal(1))
 			}
@@ -207,20 +367,736 @@ pipeline3: foo
 		}
 	})
 
+	It("logs in to each team once", func() {
+		_, err := command.Run(outRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+		Expect(otherFakeFlyCommand.LoginCallCount()).To(Equal(1))
+	})
+
+	Context("when concurrency is provided", func() {
+		BeforeEach(func() {
+			outRequest.Source.Concurrency = 3
+		})
+
+		It("sets every pipeline and returns their versions", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+			Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(1))
+
+			Expect(response.Version).To(HaveLen(3))
+		})
+	})
+
 	It("returns provided version", func() {
 		response, err := command.Run(outRequest)
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Version[apiPipelines[0]]).To(Equal("4f4bd60b18bf697cc68dac9cb95537c2"))
+		Expect(response.Version[teamName+"/"+apiPipelines[0]]).To(Equal("sha256:91f1793a5cfbf9a2739f814c00355bbbccd6361164e0b7d502e68d0abf10df60"))
+		Expect(response.Version[otherTeamName+"/"+apiPipelines[2]]).NotTo(BeEmpty())
+	})
+
+	Context("when legacy version keys are requested", func() {
+		BeforeEach(func() {
+			outRequest.Source.LegacyVersionKeys = "true"
+		})
+
+		It("returns versions keyed by pipeline name", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version[apiPipelines[0]]).To(Equal("sha256:91f1793a5cfbf9a2739f814c00355bbbccd6361164e0b7d502e68d0abf10df60"))
+			Expect(response.Version).NotTo(HaveKey(teamName + "/" + apiPipelines[0]))
+		})
+	})
+
+	Context("when a pipeline state and visibility are provided", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].State = "paused"
+			outRequest.Params.Pipelines[0].Visibility = "hidden"
+		})
+
+		It("brings the pipeline to that state and visibility after setting it", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+
+			Expect(fakeFlyCommand.PausePipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.PausePipelineArgsForCall(0)).To(Equal(pipelines[0].Ref()))
+
+			Expect(fakeFlyCommand.HidePipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.HidePipelineArgsForCall(0)).To(Equal(pipelines[0].Ref()))
+		})
+
+		Context("when the state is archived", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].State = "archived"
+			})
+
+			It("archives the pipeline without setting it", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(1))
+				ref, _, _, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+				Expect(ref).To(Equal(pipelines[1].Ref()))
+
+				Expect(fakeFlyCommand.ArchivePipelineCallCount()).To(Equal(1))
+				Expect(fakeFlyCommand.ArchivePipelineArgsForCall(0)).To(Equal(pipelines[0].Ref()))
+			})
+		})
+
+		Context("when applying the state returns an error", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.PausePipelineReturns(nil, fmt.Errorf("pause failed"))
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("pause failed"))
+			})
+		})
 	})
 
-	It("returns metadata", func() {
+	Context("when a pipeline has instance vars", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].InstanceVars = map[string]interface{}{"env": "prod"}
+		})
+
+		It("sets and returns the version of that instance", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			ref, _, _, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+			Expect(ref).To(Equal(concourse.PipelineRef{
+				Name:         apiPipelines[0],
+				InstanceVars: map[string]interface{}{"env": "prod"},
+			}))
+
+			Expect(response.Version).To(HaveKey(teamName + "/" + apiPipelines[0] + "/env:prod"))
+			Expect(response.Version).NotTo(HaveKey(teamName + "/" + apiPipelines[0]))
+		})
+
+		Context("when prune is requested", func() {
+			BeforeEach(func() {
+				outRequest.Params.Prune = true
+				fakeFlyCommand.PipelinesReturns([]concourse.PipelineRef{
+					{Name: apiPipelines[0], InstanceVars: map[string]interface{}{"env": "prod"}},
+					{Name: apiPipelines[0], InstanceVars: map[string]interface{}{"env": "dev"}},
+					{Name: apiPipelines[1]},
+				}, nil)
+			})
+
+			It("destroys the undeclared instances", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(1))
+				Expect(fakeFlyCommand.DestroyPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{
+					Name:         apiPipelines[0],
+					InstanceVars: map[string]interface{}{"env": "dev"},
+				}))
+			})
+		})
+	})
+
+	It("returns metadata listing the updated pipelines", func() {
 		response, err := command.Run(outRequest)
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Metadata).NotTo(BeNil())
+		Expect(response.Metadata[0]).To(Equal(
+			concourse.Metadata{Name: "updated", Value: "main/pipeline-1, main/pipeline-2, some-other-team/pipeline-3"},
+		))
+	})
+
+	It("returns metadata detailing each pipeline", func() {
+		response, err := command.Run(outRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(response.Metadata[1:]).To(Equal([]concourse.Metadata{
+			{
+				Name:  "main/pipeline-1",
+				Value: "status: created, paused: true, config: sha256:91f1793a5cfbf9a2739f814c00355bbbccd6361164e0b7d502e68d0abf10df60, url: some target/teams/main/pipelines/pipeline-1",
+			},
+			{
+				Name:  "main/pipeline-2",
+				Value: "status: created, paused: false, config: sha256:ad4001ed297bd082134e7cecd439d0690b4b2a31b8b7aaa5fbd777b4e17598c0, url: some target/teams/main/pipelines/pipeline-2",
+			},
+			{
+				Name:  "some-other-team/pipeline-3",
+				Value: "status: created, paused: true, config: sha256:780d293e4d9f7861a4dbc5af0572169f1d7932cfee692d7e916f4bbd73fda3a1, url: some target/teams/some-other-team/pipelines/pipeline-3",
+			},
+		}))
+	})
+
+	Context("when there are more pipelines than are listed in metadata", func() {
+		var names []string
+
+		BeforeEach(func() {
+			names = nil
+			outRequest.Params.Pipelines = nil
+			for i := 0; i < concourse.MaxPipelineMetadata+5; i++ {
+				name := fmt.Sprintf("pipeline-%d", i)
+				names = append(names, teamName+"/"+name)
+				outRequest.Params.Pipelines = append(outRequest.Params.Pipelines, concourse.Pipeline{
+					Name:       name,
+					ConfigFile: "pipeline_2.yml",
+					TeamName:   teamName,
+				})
+			}
+
+			fakeFlyCommand.GetPipelineStub = func(concourse.PipelineRef) ([]byte, error) {
+				return []byte(pipelineContents[1]), nil
+			}
+		})
+
+		It("truncates the list of updated pipelines with a count of the others", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata[0]).To(Equal(concourse.Metadata{
+				Name:  "updated",
+				Value: strings.Join(names[:concourse.MaxPipelineMetadata], ", ") + " … and 5 more",
+			}))
+			Expect(response.Metadata).To(HaveLen(1 + concourse.MaxPipelineMetadata + 1))
+		})
+	})
+
+	Context("when pipelines exist with the same config", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[:2]...), nil)
+			otherFakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[2]), nil)
+		})
+
+		It("only sets the pipelines which have changed", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(1))
+			ref, _, _, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+			Expect(ref).To(Equal(concourse.PipelineRef{Name: apiPipelines[1]}))
+
+			Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+		})
+
+		It("still applies the state of the unchanged pipelines", func() {
+			outRequest.Params.Pipelines[0].Unpaused = true
+
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(2))
+		})
+
+		It("returns metadata listing the updated and skipped pipelines separately", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata[:2]).To(Equal([]concourse.Metadata{
+				{Name: "updated", Value: "main/pipeline-2"},
+				{Name: "skipped", Value: "main/pipeline-1, some-other-team/pipeline-3"},
+			}))
+		})
+
+		It("returns metadata detailing whether each pipeline was updated and is paused", func() {
+			fakeFlyCommand.PipelinesReturns([]concourse.PipelineRef{
+				{Name: apiPipelines[0], Paused: true},
+				{Name: apiPipelines[1], Paused: true},
+			}, nil)
+
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata[2:]).To(Equal([]concourse.Metadata{
+				{
+					Name:  "main/pipeline-1",
+					Value: "status: unchanged, paused: true, config: sha256:91f1793a5cfbf9a2739f814c00355bbbccd6361164e0b7d502e68d0abf10df60, url: some target/teams/main/pipelines/pipeline-1",
+				},
+				{
+					Name:  "main/pipeline-2",
+					Value: "status: updated, paused: false, config: sha256:ad4001ed297bd082134e7cecd439d0690b4b2a31b8b7aaa5fbd777b4e17598c0, url: some target/teams/main/pipelines/pipeline-2",
+				},
+				{
+					Name:  "some-other-team/pipeline-3",
+					Value: "status: unchanged, paused: false, config: sha256:780d293e4d9f7861a4dbc5af0572169f1d7932cfee692d7e916f4bbd73fda3a1, url: some target/teams/some-other-team/pipelines/pipeline-3",
+				},
+			}))
+		})
+	})
+
+	Context("when dry run is requested", func() {
+		BeforeEach(func() {
+			outRequest.Params.DryRun = true
+			outRequest.Params.DiffDir = "diffs"
+
+			fakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[:2]...), nil)
+		})
+
+		It("does not change any pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.ExposePipelineCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+		})
+
+		It("writes a diff for each pipeline to the diff dir", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			diff, err := ioutil.ReadFile(filepath.Join(sourcesDir, "diffs", teamName, apiPipelines[0]+".diff"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(diff)).To(BeEmpty())
+
+			diff, err = ioutil.ReadFile(filepath.Join(sourcesDir, "diffs", teamName, apiPipelines[1]+".diff"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(diff)).To(ContainSubstring("--- current/%s/%s", teamName, apiPipelines[1]))
+			Expect(string(diff)).To(ContainSubstring("+++ candidate/%s/%s", teamName, apiPipelines[1]))
+			Expect(string(diff)).To(ContainSubstring("-pipeline2: foo"))
+			Expect(string(diff)).To(ContainSubstring("+pipeline2: bar"))
+
+			diff, err = ioutil.ReadFile(filepath.Join(sourcesDir, "diffs", otherTeamName, apiPipelines[2]+".diff"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(diff)).To(ContainSubstring("+pipeline3: foo"))
+		})
+
+		It("lists the pipelines of each team once", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.PipelinesCallCount()).To(Equal(1))
+			Expect(otherFakeFlyCommand.PipelinesCallCount()).To(Equal(1))
+		})
+
+		Context("when the pipelines of two teams have the same flat file name", func() {
+			BeforeEach(func() {
+				otherTeamName = teamName + "-b"
+				outRequest.Source.Teams[1].Name = otherTeamName
+				outRequest.Params.Pipelines = []concourse.Pipeline{
+					{Name: "b-c", ConfigFile: "pipeline_2.yml", TeamName: teamName},
+					{Name: "c", ConfigFile: "pipeline_3.yml", TeamName: otherTeamName},
+				}
+
+				fakeFlyCommand.PipelinesReturns(nil, nil)
+			})
+
+			It("writes the diff of each to its own file", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				diff, err := ioutil.ReadFile(filepath.Join(sourcesDir, "diffs", teamName, "b-c.diff"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(diff)).To(ContainSubstring("+pipeline2: bar"))
+
+				diff, err = ioutil.ReadFile(filepath.Join(sourcesDir, "diffs", otherTeamName, "c.diff"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(diff)).To(ContainSubstring("+pipeline3: foo"))
+			})
+		})
+
+		It("returns the versions of the current pipelines", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version).To(HaveLen(2))
+			Expect(response.Version[teamName+"/"+apiPipelines[0]]).To(Equal("sha256:91f1793a5cfbf9a2739f814c00355bbbccd6361164e0b7d502e68d0abf10df60"))
+			Expect(response.Version).To(HaveKey(teamName + "/" + apiPipelines[1]))
+		})
+
+		Context("when prune is also requested", func() {
+			BeforeEach(func() {
+				outRequest.Params.Prune = true
+				fakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[0], apiPipelines[1], "orphaned"), nil)
+			})
+
+			It("does not destroy any pipelines", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when order is also requested", func() {
+			BeforeEach(func() {
+				outRequest.Params.Order = concourse.PipelineOrder{Declared: true}
+			})
+
+			It("does not order any pipelines", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.OrderPipelinesCallCount()).To(Equal(0))
+				Expect(otherFakeFlyCommand.OrderPipelinesCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a config file does not exist", func() {
+			BeforeEach(func() {
+				err := os.Remove(filepath.Join(sourcesDir, "pipeline_2.yml"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when order is requested as declared", func() {
+		BeforeEach(func() {
+			err := json.Unmarshal([]byte(`true`), &outRequest.Params.Order)
+			Expect(err).NotTo(HaveOccurred())
+
+			outRequest.Params.Pipelines = append(outRequest.Params.Pipelines, concourse.Pipeline{
+				Name:         apiPipelines[0],
+				InstanceVars: map[string]interface{}{"env": "prod"},
+				ConfigFile:   "pipeline_1.yml",
+				TeamName:     teamName,
+			})
+		})
+
+		It("orders the pipelines of each team as they are declared", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.OrderPipelinesCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.OrderPipelinesArgsForCall(0)).To(Equal([]string{apiPipelines[0], apiPipelines[1]}))
+
+			Expect(otherFakeFlyCommand.OrderPipelinesCallCount()).To(Equal(1))
+			Expect(otherFakeFlyCommand.OrderPipelinesArgsForCall(0)).To(Equal([]string{apiPipelines[2]}))
+		})
+
+		Context("when ordering returns an error", func() {
+			BeforeEach(func() {
+				otherFakeFlyCommand.OrderPipelinesReturns(nil, fmt.Errorf("order failed"))
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("order failed"))
+			})
+		})
+	})
+
+	Context("when order is requested per team", func() {
+		BeforeEach(func() {
+			err := json.Unmarshal([]byte(`{"some-other-team": ["pipeline-3", "undeclared"]}`), &outRequest.Params.Order)
+			Expect(err).NotTo(HaveOccurred())
+
+			outRequest.Params.Pipelines = pipelines[:2]
+		})
+
+		It("orders the pipelines of only those teams, logging in to them if needed", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.OrderPipelinesCallCount()).To(Equal(0))
+
+			Expect(otherFakeFlyCommand.LoginCallCount()).To(Equal(1))
+			Expect(otherFakeFlyCommand.OrderPipelinesCallCount()).To(Equal(1))
+			Expect(otherFakeFlyCommand.OrderPipelinesArgsForCall(0)).To(Equal([]string{"pipeline-3", "undeclared"}))
+		})
+	})
+
+	Context("when order is not requested", func() {
+		It("does not order any pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.OrderPipelinesCallCount()).To(Equal(0))
+			Expect(otherFakeFlyCommand.OrderPipelinesCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when teams are requested", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.TeamsReturns([]concourse.TeamConfig{
+				{
+					Name: teamName,
+					Roles: map[string]concourse.TeamRole{
+						"owner": {Users: []string{"local:admin"}},
+					},
+				},
+				{
+					Name: otherTeamName,
+					Roles: map[string]concourse.TeamRole{
+						"owner":  {Users: []string{"local:admin"}},
+						"viewer": {Groups: []string{"github:my-org"}},
+					},
+				},
+			}, nil)
+
+			outRequest.Params.Teams = []concourse.TeamConfig{
+				{
+					Name: teamName,
+					Roles: map[string]concourse.TeamRole{
+						"owner": {Users: []string{"local:admin"}},
+					},
+				},
+				{
+					Name: otherTeamName,
+					Roles: map[string]concourse.TeamRole{
+						"owner":  {Users: []string{"local:admin", "local:other-admin"}},
+						"viewer": {Groups: []string{"github:my-org"}},
+					},
+				},
+				{
+					Name: "new-team",
+					Roles: map[string]concourse.TeamRole{
+						"member": {Groups: []string{"github:my-org:my-team"}},
+					},
+				},
+			}
+		})
+
+		It("sets the changed and new teams as the main team before setting pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, team, _ := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(team.Name).To(Equal("main"))
+
+			Expect(fakeFlyCommand.SetTeamCallCount()).To(Equal(2))
+			Expect(fakeFlyCommand.SetTeamArgsForCall(0)).To(Equal(outRequest.Params.Teams[1]))
+			Expect(fakeFlyCommand.SetTeamArgsForCall(1)).To(Equal(outRequest.Params.Teams[2]))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+		})
+
+		Context("when a team would lose a role member", func() {
+			BeforeEach(func() {
+				outRequest.Params.Teams[1].Roles = map[string]concourse.TeamRole{
+					"owner": {Users: []string{"local:admin"}},
+				}
+			})
+
+			It("refuses to set any teams or pipelines", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("allow_destructive_team_changes"))
+				Expect(err.Error()).To(ContainSubstring("team '%s' role 'viewer' group 'github:my-org'", otherTeamName))
+
+				Expect(fakeFlyCommand.SetTeamCallCount()).To(Equal(0))
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			})
+
+			Context("when destructive team changes are allowed", func() {
+				BeforeEach(func() {
+					outRequest.Params.AllowDestructiveTeamChanges = true
+				})
+
+				It("sets the teams", func() {
+					_, err := command.Run(outRequest)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeFlyCommand.SetTeamCallCount()).To(Equal(2))
+				})
+			})
+
+			Context("when teams dry run is requested", func() {
+				BeforeEach(func() {
+					outRequest.Params.TeamsDryRun = true
+				})
+
+				It("does not set any teams, but does set pipelines", func() {
+					_, err := command.Run(outRequest)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeFlyCommand.SetTeamCallCount()).To(Equal(0))
+					Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+				})
+			})
+		})
+
+		Context("when listing teams returns an error", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.TeamsReturns(nil, fmt.Errorf("teams failed"))
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("teams failed"))
+			})
+		})
+
+		Context("when setting a team returns an error", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.SetTeamReturns(nil, fmt.Errorf("set team failed"))
+			})
+
+			It("returns an error without setting pipelines", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("set team failed"))
+
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when multiple targets are provided", func() {
+		BeforeEach(func() {
+			outRequest.Source = concourse.Source{
+				Targets: []concourse.Target{
+					{
+						Name:   "prod",
+						Target: "prod target",
+						Teams:  outRequest.Source.Teams,
+					},
+					{
+						Name:   "dr",
+						Target: "dr target",
+						Teams:  outRequest.Source.Teams,
+					},
+					{
+						Name:   "staging",
+						Target: "staging target",
+						Teams:  outRequest.Source.Teams,
+					},
+				},
+			}
+
+			outRequest.Params.Pipelines[0].Targets = []string{"dr"}
+			outRequest.Params.Pipelines[1].Targets = []string{"prod", "dr"}
+			outRequest.Params.Pipelines[2].Targets = []string{"prod", "dr"}
+
+			for _, targetName := range []string{"prod", "dr", "staging"} {
+				targetFakeFlyCommand := &flyfakes.FakeCommand{}
+				targetFakeFlyCommand.GetPipelineReturns([]byte("some: config\n"), nil)
+				targetFakeFlyCommands[targetName] = targetFakeFlyCommand
+			}
+		})
+
+		It("sets each pipeline on its targets and returns versions namespaced by target", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(targetFakeFlyCommands["prod"].SetPipelineCallCount()).To(Equal(2))
+			Expect(targetFakeFlyCommands["dr"].SetPipelineCallCount()).To(Equal(3))
+			Expect(targetFakeFlyCommands["staging"].SetPipelineCallCount()).To(Equal(0))
+
+			url, _, _ := targetFakeFlyCommands["prod"].LoginArgsForCall(0)
+			Expect(url).To(Equal("prod target"))
+
+			Expect(response.Version).To(HaveLen(5))
+			Expect(response.Version).To(HaveKey("dr/" + teamName + "/" + apiPipelines[0]))
+			Expect(response.Version).To(HaveKey("prod/" + otherTeamName + "/" + apiPipelines[2]))
+			Expect(response.Version).NotTo(HaveKey("prod/" + teamName + "/" + apiPipelines[0]))
+		})
+
+		Context("when a target fails", func() {
+			BeforeEach(func() {
+				targetFakeFlyCommands["prod"].SetPipelineReturns(nil, fmt.Errorf("set failed"))
+			})
+
+			It("still puts to the other targets before returning an error naming the failed target", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("failed to put to targets: prod"))
+
+				Expect(targetFakeFlyCommands["dr"].SetPipelineCallCount()).To(Equal(3))
+			})
+		})
+	})
+
+	Context("when prune is requested", func() {
+		BeforeEach(func() {
+			outRequest.Params.Prune = true
+			outRequest.Params.ProtectedPipelines = []string{"protected-*"}
+
+			fakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[0], apiPipelines[1], "orphaned", "protected-orphaned"), nil)
+			otherFakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[2], "other-orphaned"), nil)
+		})
+
+		It("destroys the undeclared, unprotected pipelines of each team", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.DestroyPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{Name: "orphaned"}))
+			Expect(otherFakeFlyCommand.DestroyPipelineCallCount()).To(Equal(1))
+			Expect(otherFakeFlyCommand.DestroyPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{Name: "other-orphaned"}))
+		})
+
+		Context("when a team has no declared pipelines", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines = pipelines[:2]
+			})
+
+			It("does not prune that team", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(1))
+				Expect(fakeFlyCommand.DestroyPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{Name: "orphaned"}))
+				Expect(otherFakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when dry run is requested", func() {
+			BeforeEach(func() {
+				outRequest.Params.PruneDryRun = true
+			})
+
+			It("does not destroy any pipelines", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+				Expect(otherFakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when destroying a pipeline returns an error", func() {
+			var (
+				expectedErr error
+			)
+
+			BeforeEach(func() {
+				expectedErr = fmt.Errorf("some error")
+				fakeFlyCommand.DestroyPipelineReturns(nil, expectedErr)
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(Equal(expectedErr))
+			})
+		})
+
+		Context("when getting pipelines returns an error", func() {
+			var (
+				expectedErr error
+			)
+
+			BeforeEach(func() {
+				expectedErr = fmt.Errorf("some error")
+				fakeFlyCommand.PipelinesReturns(nil, expectedErr)
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(Equal(expectedErr))
+			})
+		})
+	})
+
+	Context("when prune is not requested", func() {
+		It("does not destroy any pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			Expect(otherFakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+		})
 	})
 
 	Context("when insecure parses as true", func() {
@@ -232,10 +1108,32 @@ pipeline3: foo
 			_, err := command.Run(outRequest)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(5))
-			_, _, _, _, insecure := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
 
-			Expect(insecure).To(BeTrue())
+			Expect(tlsConfig.Insecure).To(BeTrue())
+		})
+	})
+
+	Context("when a CA certificate and client certificate are provided", func() {
+		BeforeEach(func() {
+			outRequest.Source.CACert = "some-ca-cert"
+			outRequest.Source.ClientCert = "some-client-cert"
+			outRequest.Source.ClientKey = "some-client-key"
+		})
+
+		It("invokes the login with them", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+			_, _, tlsConfig := fakeFlyCommand.LoginArgsForCall(0)
+
+			Expect(tlsConfig).To(Equal(concourse.TLSConfig{
+				CACert:     "some-ca-cert",
+				ClientCert: "some-client-cert",
+				ClientKey:  "some-client-key",
+			}))
 		})
 	})
 
@@ -288,6 +1186,297 @@ pipeline3: foo
 		})
 	})
 
+	Context("when atomic is requested", func() {
+		var (
+			restoredConfigs map[string]string
+			otherSetErr     error
+		)
+
+		BeforeEach(func() {
+			outRequest.Params.Atomic = true
+
+			restoredConfigs = map[string]string{}
+			otherSetErr = fmt.Errorf("some error")
+
+			fakeFlyCommand.PipelinesReturns(pipelineRefs(apiPipelines[0]), nil)
+
+			err := ioutil.WriteFile(filepath.Join(sourcesDir, "pipeline_1.yml"), []byte("pipeline1: changed\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		// Setting the return values of SetPipeline clears any stub
+		JustBeforeEach(func() {
+			fakeFlyCommand.SetPipelineStub = func(
+				ref concourse.PipelineRef,
+				configFilepath string,
+				varsFilepaths []string,
+				vars map[string]interface{},
+			) ([]byte, error) {
+				if filepath.Dir(configFilepath) != sourcesDir {
+					contents, err := ioutil.ReadFile(configFilepath)
+					Expect(err).NotTo(HaveOccurred())
+					restoredConfigs[ref.String()] = string(contents)
+				}
+
+				return nil, nil
+			}
+
+			otherFakeFlyCommand.SetPipelineStub = func(
+				concourse.PipelineRef,
+				string,
+				[]string,
+				map[string]interface{},
+			) ([]byte, error) {
+				return nil, otherSetErr
+			}
+		})
+
+		It("restores the existing pipelines and destroys the new ones which were set", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError(
+				"some error\n" +
+					"rolled back:\n" +
+					"pipeline 'pipeline-1' in team 'main': restored\n" +
+					"pipeline 'pipeline-2' in team 'main': destroyed",
+			))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(3))
+			Expect(restoredConfigs).To(Equal(map[string]string{
+				"pipeline-1": pipelineContents[0],
+			}))
+
+			Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.DestroyPipelineArgsForCall(0)).To(Equal(concourse.PipelineRef{Name: "pipeline-2"}))
+
+			Expect(otherFakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+		})
+
+		Context("when an existing pipeline was archived", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].State = concourse.PipelineStateArchived
+			})
+
+			It("restores it from its snapshot", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError(
+					"some error\n" +
+						"rolled back:\n" +
+						"pipeline 'pipeline-1' in team 'main': restored\n" +
+						"pipeline 'pipeline-2' in team 'main': destroyed",
+				))
+
+				Expect(fakeFlyCommand.ArchivePipelineCallCount()).To(Equal(1))
+				Expect(restoredConfigs).To(Equal(map[string]string{
+					"pipeline-1": pipelineContents[0],
+				}))
+			})
+		})
+
+		Context("when a pipeline which is not listed was archived", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[1].State = concourse.PipelineStateArchived
+			})
+
+			It("is not rolled back, as it was already archived", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError(
+					"some error\n" +
+						"rolled back:\n" +
+						"pipeline 'pipeline-1' in team 'main': restored",
+				))
+
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the rollback fails", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.DestroyPipelineReturns(nil, fmt.Errorf("some destroy error"))
+			})
+
+			It("returns an error with the outcome of the rollback", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError(
+					"some error\n" +
+						"rollback failed:\n" +
+						"pipeline 'pipeline-1' in team 'main': restored\n" +
+						"pipeline 'pipeline-2' in team 'main': failed to roll back: some destroy error",
+				))
+			})
+		})
+
+		Context("when every pipeline is set", func() {
+			BeforeEach(func() {
+				otherSetErr = nil
+			})
+
+			It("does not roll back", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(restoredConfigs).To(BeEmpty())
+				Expect(fakeFlyCommand.DestroyPipelineCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the pipelines of two teams have the same flat file name", func() {
+			var restoredTeamConfigs map[string]string
+
+			BeforeEach(func() {
+				restoredTeamConfigs = map[string]string{}
+
+				otherTeamName = teamName + "-b"
+				outRequest.Source.Teams[1].Name = otherTeamName
+				outRequest.Params.Pipelines = []concourse.Pipeline{
+					{Name: "b-c", ConfigFile: "pipeline_2.yml", TeamName: teamName},
+					{Name: "c", ConfigFile: "pipeline_3.yml", TeamName: otherTeamName},
+					{Name: "d", ConfigFile: "pipeline_3.yml", TeamName: teamName},
+				}
+
+				fakeFlyCommand.PipelinesReturns(pipelineRefs("b-c"), nil)
+				otherFakeFlyCommand.PipelinesReturns(pipelineRefs("c"), nil)
+
+				fakeFlyCommand.GetPipelineStub = func(concourse.PipelineRef) ([]byte, error) {
+					return []byte("main: config\n"), nil
+				}
+				otherFakeFlyCommand.GetPipelineStub = func(concourse.PipelineRef) ([]byte, error) {
+					return []byte("other: config\n"), nil
+				}
+			})
+
+			JustBeforeEach(func() {
+				restoringStub := func(team string, setErr error) func(
+					concourse.PipelineRef,
+					string,
+					[]string,
+					map[string]interface{},
+				) ([]byte, error) {
+					return func(
+						ref concourse.PipelineRef,
+						configFilepath string,
+						_ []string,
+						_ map[string]interface{},
+					) ([]byte, error) {
+						if filepath.Dir(configFilepath) != sourcesDir {
+							contents, err := ioutil.ReadFile(configFilepath)
+							Expect(err).NotTo(HaveOccurred())
+							restoredTeamConfigs[team+"/"+ref.String()] = string(contents)
+							return nil, nil
+						}
+
+						if ref.Name == "d" {
+							return nil, setErr
+						}
+						return nil, nil
+					}
+				}
+
+				fakeFlyCommand.SetPipelineStub = restoringStub(teamName, fmt.Errorf("some error"))
+				otherFakeFlyCommand.SetPipelineStub = restoringStub(otherTeamName, nil)
+			})
+
+			It("restores each pipeline from its own snapshot", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(restoredTeamConfigs).To(Equal(map[string]string{
+					"main/b-c": "main: config\n",
+					"main-b/c": "other: config\n",
+				}))
+			})
+		})
+
+		Context("when getting the snapshot returns an error", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.GetPipelineStub = nil
+				fakeFlyCommand.GetPipelineReturns(nil, fmt.Errorf("some get error"))
+			})
+
+			It("returns an error without setting any pipeline", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("some get error"))
+
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when fail fast is disabled", func() {
+		BeforeEach(func() {
+			failFast := false
+			outRequest.Params.FailFast = &failFast
+		})
+
+		JustBeforeEach(func() {
+			fakeFlyCommand.SetPipelineStub = func(
+				ref concourse.PipelineRef,
+				_ string,
+				_ []string,
+				_ map[string]interface{},
+			) ([]byte, error) {
+				if ref.Name == apiPipelines[0] {
+					return nil, fmt.Errorf("some error")
+				}
+
+				return nil, nil
+			}
+		})
+
+		It("sets every other pipeline and returns all the errors", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError("failed to set pipelines:\npipeline 'pipeline-1' in team 'main': some error"))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+			Expect(otherFakeFlyCommand.SetPipelineCallCount()).To(Equal(1))
+			Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(1))
+		})
+
+		It("writes the result of each pipeline to the report", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			report, err := ioutil.ReadFile(filepath.Join(sourcesDir, "out-report.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(MatchJSON(`[
+				{"team": "main", "pipeline": "pipeline-1", "action": "none", "error": "some error"},
+				{"team": "main", "pipeline": "pipeline-2", "action": "set"},
+				{"team": "some-other-team", "pipeline": "pipeline-3", "action": "set"}
+			]`))
+		})
+	})
+
+	Context("when a report file is provided", func() {
+		BeforeEach(func() {
+			outRequest.Params.ReportFile = "reports/report.json"
+			outRequest.Params.Pipelines[2].State = concourse.PipelineStateArchived
+		})
+
+		It("writes the result of each pipeline to it", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			report, err := ioutil.ReadFile(filepath.Join(sourcesDir, "reports", "report.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report).To(MatchJSON(`[
+				{"team": "main", "pipeline": "pipeline-1", "action": "set"},
+				{"team": "main", "pipeline": "pipeline-2", "action": "set"},
+				{"team": "some-other-team", "pipeline": "pipeline-3", "action": "archived"}
+			]`))
+		})
+	})
+
+	Context("when no report file is provided", func() {
+		It("does not write a report", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(sourcesDir, "out-report.json"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
 	Context("when getting pipeline returns an error", func() {
 		var (
 			expectedErr error
@@ -307,3 +1496,11 @@ pipeline3: foo
 		})
 	})
 })
+
+func pipelineRefs(names ...string) []concourse.PipelineRef {
+	refs := make([]concourse.PipelineRef, len(names))
+	for i, name := range names {
+		refs[i] = concourse.PipelineRef{Name: name}
+	}
+	return refs
+}
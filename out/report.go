@@ -0,0 +1,144 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+const (
+	actionNone      = "none"
+	actionSet       = "set"
+	actionUnchanged = "unchanged"
+	actionArchived  = "archived"
+
+	defaultReportFile = "out-report.json"
+)
+
+// pipelineResult is what was done to a single pipeline, and why it failed,
+// if it did.
+type pipelineResult struct {
+	Target   string `json:"target,omitempty"`
+	Team     string `json:"team"`
+	Pipeline string `json:"pipeline"`
+	Action   string `json:"action"`
+	Error    string `json:"error,omitempty"`
+
+	// metadata is only known once all the pipelines of the target are set.
+	metadata concourse.PipelineMetadata
+}
+
+// reportFilepath returns the path of the file to write the results of the
+// pipelines to, or an empty path if no report is to be written.
+func (c *Command) reportFilepath(params concourse.OutParams) string {
+	reportFile := params.ReportFile
+	if reportFile == "" {
+		if params.FailsFast() {
+			return ""
+		}
+		reportFile = defaultReportFile
+	}
+
+	return filepath.Join(c.sourcesDir, reportFile)
+}
+
+// pipelineMetadata lists the pipelines which were updated and those which
+// were skipped as they were unchanged, followed by the details of each.
+func pipelineMetadata(results []pipelineResult) []concourse.Metadata {
+	var updated, skipped []string
+	for _, result := range results {
+		name := concourse.TargetVersionKey(result.Target, fmt.Sprintf("%s/%s", result.Team, result.Pipeline))
+
+		switch result.Action {
+		case actionSet:
+			updated = append(updated, name)
+		case actionUnchanged:
+			skipped = append(skipped, name)
+		}
+	}
+
+	var metadata []concourse.Metadata
+	if len(updated) > 0 {
+		metadata = append(metadata, concourse.Metadata{Name: "updated", Value: joinNames(updated)})
+	}
+	if len(skipped) > 0 {
+		metadata = append(metadata, concourse.Metadata{Name: "skipped", Value: joinNames(skipped)})
+	}
+
+	var pipelines []concourse.PipelineMetadata
+	for _, result := range results {
+		pipelines = append(pipelines, result.metadata)
+	}
+
+	return append(metadata, concourse.PipelinesMetadata(pipelines)...)
+}
+
+// joinNames lists at most concourse.MaxPipelineMetadata names followed by a
+// count of the others.
+func joinNames(names []string) string {
+	if len(names) <= concourse.MaxPipelineMetadata {
+		return strings.Join(names, ", ")
+	}
+
+	return fmt.Sprintf(
+		"%s … and %d more",
+		strings.Join(names[:concourse.MaxPipelineMetadata], ", "),
+		len(names)-concourse.MaxPipelineMetadata,
+	)
+}
+
+// resultRef returns the ref of the pipeline as it is once set, which is
+// paused as set by its state or else as it was, with new pipelines paused
+// as Concourse creates them.
+func resultRef(p concourse.Pipeline, current concourse.PipelineRef, exists bool) concourse.PipelineRef {
+	ref := p.Ref()
+
+	switch p.DesiredState() {
+	case concourse.PipelineStatePaused, concourse.PipelineStateArchived:
+		ref.Paused = true
+	case concourse.PipelineStateUnpaused:
+		ref.Paused = false
+	default:
+		ref.Paused = !exists || current.Paused
+	}
+
+	return ref
+}
+
+// resultStatus returns how the pipeline was put given the action taken.
+func resultStatus(action string, exists bool) string {
+	switch action {
+	case actionSet:
+		if exists {
+			return concourse.PipelineStatusUpdated
+		}
+		return concourse.PipelineStatusCreated
+	case actionArchived:
+		return concourse.PipelineStatusArchived
+	default:
+		return concourse.PipelineStatusUnchanged
+	}
+}
+
+func writeReport(reportFilepath string, results []pipelineResult) error {
+	if results == nil {
+		results = []pipelineResult{}
+	}
+
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(reportFilepath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(reportFilepath, append(b, '\n'), os.ModePerm)
+}
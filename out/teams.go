@@ -0,0 +1,131 @@
+package out
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// setTeams applies the auth config of each team in the teams param as the
+// main team. Removing anyone from a role of an existing team is refused
+// unless destructive changes are allowed, in which case no team is set.
+func (c *Command) setTeams(
+	targetName string,
+	input concourse.OutRequest,
+	teams map[string]concourse.Team,
+	tlsConfig concourse.TLSConfig,
+) error {
+	mainTeam, found := teams[concourse.MainTeamName]
+	if !found {
+		return fmt.Errorf("team (%s) configuration not found for setting teams", concourse.MainTeamName)
+	}
+
+	flyCommand := c.flyCommands(targetName, concourse.MainTeamName)
+
+	c.logger.Debugf("Performing login (%s)\n", concourse.MainTeamName)
+	_, err := flyCommand.Login(input.Source.Target, mainTeam, tlsConfig)
+	if err != nil {
+		return err
+	}
+	c.logger.Debugf("Login successful (%s)\n", concourse.MainTeamName)
+
+	existingTeams, err := flyCommand.Teams()
+	if err != nil {
+		return err
+	}
+	c.logger.Debugf("Found teams: %+v\n", existingTeams)
+
+	existing := make(map[string]concourse.TeamConfig)
+	for _, team := range existingTeams {
+		existing[team.Name] = team
+	}
+
+	dryRun := input.Params.DryRun || input.Params.TeamsDryRun
+	allowed := input.Params.AllowDestructiveTeamChanges
+
+	var (
+		changed  []concourse.TeamConfig
+		removals []string
+	)
+	changes := make(map[string][]string)
+
+	for _, team := range input.Params.Teams {
+		current := existing[team.Name]
+
+		removed := roleMembers(current, team)
+		added := roleMembers(team, current)
+
+		if _, found := existing[team.Name]; found && len(removed) == 0 && len(added) == 0 {
+			fmt.Fprintf(os.Stderr, "team '%s' is unchanged\n", team.Name)
+			continue
+		}
+
+		for _, r := range removed {
+			changes[team.Name] = append(changes[team.Name], "- "+r)
+			removals = append(removals, fmt.Sprintf("team '%s' %s", team.Name, r))
+		}
+		for _, a := range added {
+			changes[team.Name] = append(changes[team.Name], "+ "+a)
+		}
+
+		changed = append(changed, team)
+	}
+
+	if len(removals) > 0 && !allowed && !dryRun {
+		return fmt.Errorf(
+			"refusing to remove the following from team roles unless %s is set:\n%s",
+			"allow_destructive_team_changes",
+			strings.Join(removals, "\n"),
+		)
+	}
+
+	for _, team := range changed {
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "team '%s' would be set (dry run):\n%s\n", team.Name, strings.Join(changes[team.Name], "\n"))
+			continue
+		}
+
+		setOutput, err := flyCommand.SetTeam(team)
+		c.logger.Debugf("team '%s' set; output:\n\n%s\n", team.Name, string(setOutput))
+		fmt.Fprintf(os.Stderr, "team '%s' set:\n%s\n", team.Name, strings.Join(changes[team.Name], "\n"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(removals) > 0 && !allowed {
+		fmt.Fprintf(os.Stderr, "destructive team changes would be refused unless %s is set (dry run)\n", "allow_destructive_team_changes")
+	}
+
+	return nil
+}
+
+// roleMembers returns the users and groups which have a role in team a but
+// not in team b, sorted by role.
+func roleMembers(a concourse.TeamConfig, b concourse.TeamConfig) []string {
+	var roleNames []string
+	for roleName := range a.Roles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+
+	var members []string
+	for _, roleName := range roleNames {
+		for _, user := range a.Roles[roleName].Users {
+			if !stringContains(b.Roles[roleName].Users, user) {
+				members = append(members, fmt.Sprintf("role '%s' user '%s'", roleName, user))
+			}
+		}
+
+		for _, group := range a.Roles[roleName].Groups {
+			if !stringContains(b.Roles[roleName].Groups, group) {
+				members = append(members, fmt.Sprintf("role '%s' group '%s'", roleName, group))
+			}
+		}
+	}
+
+	return members
+}
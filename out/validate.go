@@ -0,0 +1,77 @@
+package out
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+)
+
+// validatePipelines validates the interpolated config of each pipeline which
+// is to be set, before any is set, so that one invalid config does not leave
+// the pipelines half updated. The errors of all invalid configs are returned
+// together.
+func (c *Command) validatePipelines(
+	input concourse.OutRequest,
+	flyCommands map[string]fly.Command,
+) error {
+	pipelines := input.Params.Pipelines
+	invalid := make([]error, len(pipelines))
+
+	err := parallel.Run(len(pipelines), input.Source.Concurrency, func(i int) error {
+		p := pipelines[i]
+
+		// Archived pipelines are not set
+		if p.DesiredState() == concourse.PipelineStateArchived {
+			return nil
+		}
+
+		configFilepath, varsFilepaths := c.pipelineFilepaths(p)
+
+		config, err := pipelineconfig.Render(configFilepath, varsFilepaths, p.Vars)
+		if err != nil {
+			invalid[i] = fmt.Errorf("pipeline '%s' in team '%s': %v", p.Ref(), p.TeamName, err)
+			return nil
+		}
+
+		configFile, err := ioutil.TempFile("", "concourse-pipeline-resource-config")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(configFile.Name())
+
+		_, err = configFile.Write(config)
+		configFile.Close()
+		if err != nil {
+			return err
+		}
+
+		output, err := flyCommands[p.TeamName].ValidatePipeline(configFile.Name(), input.Params.StrictValidation)
+		c.logger.Debugf("pipeline '%s' validated; output:\n\n%s\n", p.Ref(), string(output))
+		if err != nil {
+			invalid[i] = fmt.Errorf("pipeline '%s' in team '%s': %v", p.Ref(), p.TeamName, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs parallel.Errors
+	for _, err := range invalid {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid pipeline configs:\n%s", errs)
+	}
+
+	return nil
+}
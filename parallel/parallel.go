@@ -0,0 +1,77 @@
+package parallel
+
+import (
+	"strings"
+	"sync"
+)
+
+// Errors joins several errors, e.g. those of calls which collect their own
+// errors rather than fail, one per line.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Run calls fn with each index from 0 to n-1, with at most concurrency calls
+// running at once. Once a call has failed no further calls are started, so a
+// concurrency of 1 behaves as a plain loop which stops at the first error.
+//
+// Calls already started are finished, and the error of the lowest index which
+// failed is returned. As calls start in index order, every call before it was
+// started, so the error does not depend on the timing of the calls.
+func Run(n int, concurrency int, fn func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		failed bool
+	)
+
+	errs := make([]error, n)
+	slots := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+
+		mutex.Lock()
+		stop := failed
+		mutex.Unlock()
+
+		if stop {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := fn(i)
+			if err != nil {
+				mutex.Lock()
+				errs[i] = err
+				failed = true
+				mutex.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
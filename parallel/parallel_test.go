@@ -0,0 +1,150 @@
+package parallel_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/parallel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		mutex   sync.Mutex
+		called  []int
+		running int
+		maxSeen int
+	)
+
+	BeforeEach(func() {
+		called = nil
+		running = 0
+		maxSeen = 0
+	})
+
+	record := func(i int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		called = append(called, i)
+	}
+
+	It("calls the function for each index", func() {
+		err := parallel.Run(5, 3, func(i int) error {
+			record(i)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(called).To(ConsistOf(0, 1, 2, 3, 4))
+	})
+
+	It("runs no more than the given number of calls at once", func() {
+		release := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			Eventually(func() int {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return running
+			}).Should(Equal(2))
+
+			close(release)
+		}()
+
+		err := parallel.Run(6, 2, func(i int) error {
+			mutex.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mutex.Unlock()
+
+			<-release
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(maxSeen).To(Equal(2))
+	})
+
+	Context("when the concurrency is less than 1", func() {
+		It("calls the functions one at a time in order", func() {
+			err := parallel.Run(3, 0, func(i int) error {
+				record(i)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(called).To(Equal([]int{0, 1, 2}))
+		})
+	})
+
+	Context("when a call fails", func() {
+		var (
+			expectedErr error
+		)
+
+		BeforeEach(func() {
+			expectedErr = fmt.Errorf("some error")
+		})
+
+		It("returns the error and starts no further calls", func() {
+			err := parallel.Run(3, 1, func(i int) error {
+				record(i)
+				if i == 1 {
+					return expectedErr
+				}
+				return nil
+			})
+			Expect(err).To(Equal(expectedErr))
+
+			Expect(called).To(Equal([]int{0, 1}))
+		})
+	})
+
+	Context("when several calls fail", func() {
+		It("returns the error of the lowest index", func() {
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+
+			go func() {
+				<-started
+				<-started
+				close(release)
+			}()
+
+			err := parallel.Run(2, 2, func(i int) error {
+				started <- struct{}{}
+				<-release
+				return fmt.Errorf("error %d", i)
+			})
+			Expect(err).To(Equal(fmt.Errorf("error 0")))
+		})
+
+		It("returns the same error whichever call fails first", func() {
+			failed := make(chan struct{})
+
+			err := parallel.Run(3, 2, func(i int) error {
+				switch i {
+				case 0:
+					<-failed
+					return fmt.Errorf("error 0")
+				case 1:
+					defer close(failed)
+					return fmt.Errorf("error 1")
+				default:
+					return fmt.Errorf("error %d", i)
+				}
+			})
+			Expect(err).To(Equal(fmt.Errorf("error 0")))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+package pipelineconfig
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v2"
+)
+
+// Normalize parses the pipeline config and re-encodes it as YAML with all
+// keys sorted, so that configs from different sources can be compared.
+func Normalize(config []byte) ([]byte, error) {
+	parsed, err := parse(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed == nil {
+		return []byte{}, nil
+	}
+
+	return yaml.Marshal(parsed)
+}
+
+// Diff returns a unified diff between the normalized forms of the current and
+// candidate pipeline configs. An empty current config represents a pipeline
+// which does not yet exist. An empty diff is returned if they are equivalent.
+func Diff(
+	current []byte,
+	candidate []byte,
+	currentLabel string,
+	candidateLabel string,
+) (string, error) {
+	normalizedCurrent, err := Normalize(current)
+	if err != nil {
+		return "", err
+	}
+
+	normalizedCandidate, err := Normalize(candidate)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(normalizedCurrent),
+		B:        splitLines(normalizedCandidate),
+		FromFile: currentLabel,
+		ToFile:   candidateLabel,
+		Context:  3,
+	})
+}
+
+// splitLines splits the config into lines, keeping their line endings. Unlike
+// difflib.SplitLines no trailing empty line is added.
+func splitLines(config []byte) []string {
+	lines := strings.SplitAfter(string(config), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package pipelineconfig_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	It("returns an empty diff for equivalent configs", func() {
+		diff, err := pipelineconfig.Diff(
+			[]byte("b: x\na: 1\n"),
+			[]byte("---\na: 1\nb: \"x\"\n"),
+			"current",
+			"candidate",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(BeEmpty())
+	})
+
+	It("returns a unified diff of the normalized configs", func() {
+		diff, err := pipelineconfig.Diff(
+			[]byte("b: 2\na: 1\n"),
+			[]byte("a: 1\nb: 3\n"),
+			"current",
+			"candidate",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(Equal("--- current\n+++ candidate\n@@ -1,2 +1,2 @@\n a: 1\n-b: 2\n+b: 3\n"))
+	})
+
+	It("treats configs as equivalent exactly when their versions match", func() {
+		current := []byte("params:\n  1: x\n")
+		candidate := []byte("params:\n  \"1\": x\n")
+
+		diff, err := pipelineconfig.Diff(current, candidate, "current", "candidate")
+		Expect(err).NotTo(HaveOccurred())
+
+		currentVersion, err := pipelineconfig.Version(current)
+		Expect(err).NotTo(HaveOccurred())
+
+		candidateVersion, err := pipelineconfig.Version(candidate)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(candidateVersion).To(Equal(currentVersion))
+		Expect(diff).To(BeEmpty())
+	})
+
+	Context("when there is no current config", func() {
+		It("returns the whole candidate config as added", func() {
+			diff, err := pipelineconfig.Diff(
+				nil,
+				[]byte("a: 1\n"),
+				"current",
+				"candidate",
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff).To(ContainSubstring("+a: 1"))
+		})
+	})
+
+	Context("when a config is not valid YAML", func() {
+		It("returns an error", func() {
+			_, err := pipelineconfig.Diff([]byte("{{{"), []byte("a: 1\n"), "current", "candidate")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
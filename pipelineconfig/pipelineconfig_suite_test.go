@@ -0,0 +1,13 @@
+package pipelineconfig_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPipelineconfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pipelineconfig Suite")
+}
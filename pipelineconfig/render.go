@@ -0,0 +1,164 @@
+package pipelineconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var varRegexp = regexp.MustCompile(`\(\(([-/\.\w\pL]+)\)\)`)
+
+// Render reads the pipeline config at configFilepath and interpolates any
+// ((var)) placeholders with the values from the vars files (in order) and
+// then vars, mirroring `fly set-pipeline -l ... -y ...`.
+//
+// Placeholders which cannot be resolved are left in place so that they can be
+// resolved at runtime by a credential manager.
+func Render(
+	configFilepath string,
+	varsFilepaths []string,
+	vars map[string]interface{},
+) ([]byte, error) {
+	configBytes, err := ioutil.ReadFile(configFilepath)
+	if err != nil {
+		return nil, err
+	}
+
+	allVars := map[interface{}]interface{}{}
+
+	for _, vf := range varsFilepaths {
+		varsBytes, err := ioutil.ReadFile(vf)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileVars map[interface{}]interface{}
+		err = yaml.Unmarshal(varsBytes, &fileVars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse vars file '%s': %v", vf, err)
+		}
+
+		for k, v := range fileVars {
+			allVars[k] = v
+		}
+	}
+
+	for k, v := range vars {
+		allVars[k] = v
+	}
+
+	var config yaml.MapSlice
+	err = yaml.Unmarshal(configBytes, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%s': %v", configFilepath, err)
+	}
+
+	interpolated, err := interpolate(config, allVars)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(interpolated)
+}
+
+func interpolate(node interface{}, vars map[interface{}]interface{}) (interface{}, error) {
+	switch typed := node.(type) {
+	case yaml.MapSlice:
+		out := make(yaml.MapSlice, len(typed))
+		for i, item := range typed {
+			key, err := interpolate(item.Key, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := interpolate(item.Value, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = yaml.MapItem{Key: key, Value: value}
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			value, err := interpolate(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = value
+		}
+		return out, nil
+
+	case string:
+		return interpolateString(typed, vars)
+	}
+
+	return node, nil
+}
+
+func interpolateString(s string, vars map[interface{}]interface{}) (interface{}, error) {
+	// A value consisting solely of a placeholder is replaced with the value
+	// itself, preserving its type.
+	if match := varRegexp.FindStringSubmatch(s); match != nil && match[0] == s {
+		value, found := lookup(match[1], vars)
+		if !found {
+			return s, nil
+		}
+		return value, nil
+	}
+
+	var err error
+	result := varRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := varRegexp.FindStringSubmatch(placeholder)[1]
+
+		value, found := lookup(name, vars)
+		if !found {
+			return placeholder
+		}
+
+		switch value.(type) {
+		case string, int, int64, float64, bool:
+			return fmt.Sprintf("%v", value)
+		default:
+			err = fmt.Errorf("var '%s' must be a primitive value to be interpolated into '%s'", name, s)
+			return placeholder
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func lookup(name string, vars map[interface{}]interface{}) (interface{}, bool) {
+	segments := strings.Split(name, ".")
+
+	value, found := vars[segments[0]]
+	if !found {
+		return nil, false
+	}
+
+	for _, segment := range segments[1:] {
+		switch typed := value.(type) {
+		case map[interface{}]interface{}:
+			value, found = typed[segment]
+		case map[string]interface{}:
+			value, found = typed[segment]
+		default:
+			found = false
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,186 @@
+package pipelineconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Render", func() {
+	var (
+		tempDir string
+
+		configFilepath string
+		varsFilepaths  []string
+		vars           map[string]interface{}
+
+		configContents string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		configFilepath = filepath.Join(tempDir, "pipeline.yml")
+		varsFilepaths = nil
+		vars = nil
+
+		configContents = `---
+resources:
+- name: repo
+  type: git
+  source:
+    uri: ((uri))
+    branch: release-((version))
+    private_key: ((private_key))
+jobs:
+- name: build
+  public: ((public))
+  plan:
+  - get: repo
+`
+	})
+
+	JustBeforeEach(func() {
+		err := ioutil.WriteFile(configFilepath, []byte(configContents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("leaves unresolved placeholders in place", func() {
+		output, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(output)).To(ContainSubstring("uri: ((uri))"))
+		Expect(string(output)).To(ContainSubstring("branch: release-((version))"))
+		Expect(string(output)).To(ContainSubstring("public: ((public))"))
+	})
+
+	It("preserves the order of keys", func() {
+		output, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(output)).To(MatchRegexp("(?s)resources:.*jobs:"))
+	})
+
+	Context("when vars are provided", func() {
+		BeforeEach(func() {
+			vars = map[string]interface{}{
+				"uri":     "https://example.com/repo.git",
+				"version": 2,
+				"public":  true,
+			}
+		})
+
+		It("interpolates the vars", func() {
+			output, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(ContainSubstring("uri: https://example.com/repo.git"))
+			Expect(string(output)).To(ContainSubstring("branch: release-2"))
+			Expect(string(output)).To(ContainSubstring("public: true"))
+			Expect(string(output)).To(ContainSubstring("private_key: ((private_key))"))
+		})
+
+		Context("when a non-primitive var is interpolated into a string", func() {
+			BeforeEach(func() {
+				vars["version"] = map[string]interface{}{"major": 2}
+			})
+
+			It("returns an error", func() {
+				_, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*version.*primitive"))
+			})
+		})
+	})
+
+	Context("when vars files are provided", func() {
+		BeforeEach(func() {
+			varsFilepaths = []string{
+				filepath.Join(tempDir, "vars-1.yml"),
+				filepath.Join(tempDir, "vars-2.yml"),
+			}
+
+			err := ioutil.WriteFile(varsFilepaths[0], []byte("uri: first-uri\nversion: 1\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(varsFilepaths[1], []byte("version: 2\ngit:\n  key: some-key\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			configContents = `---
+resources:
+- name: repo
+  source:
+    uri: ((uri))
+    branch: release-((version))
+    private_key: ((git.key))
+`
+		})
+
+		It("interpolates the vars, with later files taking precedence", func() {
+			output, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(ContainSubstring("uri: first-uri"))
+			Expect(string(output)).To(ContainSubstring("branch: release-2"))
+			Expect(string(output)).To(ContainSubstring("private_key: some-key"))
+		})
+
+		Context("when vars are also provided", func() {
+			BeforeEach(func() {
+				vars = map[string]interface{}{
+					"version": 3,
+				}
+			})
+
+			It("gives vars precedence over vars files", func() {
+				output, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring("branch: release-3"))
+			})
+		})
+
+		Context("when a vars file does not exist", func() {
+			BeforeEach(func() {
+				varsFilepaths = append(varsFilepaths, filepath.Join(tempDir, "missing.yml"))
+			})
+
+			It("returns an error", func() {
+				_, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when the config file does not exist", func() {
+		It("returns an error", func() {
+			_, err := pipelineconfig.Render(filepath.Join(tempDir, "missing.yml"), varsFilepaths, vars)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the config file is not valid YAML", func() {
+		BeforeEach(func() {
+			configContents = "{{{"
+		})
+
+		It("returns an error", func() {
+			_, err := pipelineconfig.Render(configFilepath, varsFilepaths, vars)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*parse config file"))
+		})
+	})
+})
@@ -0,0 +1,86 @@
+package pipelineconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// HashAlgorithm is recorded as a prefix of every version so that the
+// algorithm can be changed in future without invalidating existing versions.
+const HashAlgorithm = "sha256"
+
+// Version returns a hash of the pipeline config which is stable across
+// cosmetic changes such as key ordering, whitespace and quoting.
+func Version(config []byte) (string, error) {
+	canonical, err := Canonicalize(config)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%x", HashAlgorithm, sha256.Sum256(canonical)), nil
+}
+
+// Canonicalize parses the pipeline config and re-encodes it as JSON with all
+// keys sorted.
+func Canonicalize(config []byte) ([]byte, error) {
+	parsed, err := parse(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/json sorts map keys, which is what makes this canonical
+	return json.Marshal(parsed)
+}
+
+// JSON parses the pipeline config and re-encodes it as indented JSON, with
+// all keys sorted.
+func JSON(config []byte) ([]byte, error) {
+	canonical, err := Canonicalize(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var indented bytes.Buffer
+	err = json.Indent(&indented, canonical, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(indented.Bytes(), '\n'), nil
+}
+
+// parse parses the pipeline config with every map key as a string, which is
+// the form in which configs are compared, whether for versions or diffs.
+func parse(config []byte) (interface{}, error) {
+	var parsed interface{}
+	err := yaml.Unmarshal(config, &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline config: %v", err)
+	}
+
+	return normalize(parsed), nil
+}
+
+func normalize(node interface{}) interface{} {
+	switch typed := node.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			out[fmt.Sprintf("%v", k)] = normalize(v)
+		}
+		return out
+
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, v := range typed {
+			out[i] = normalize(v)
+		}
+		return out
+	}
+
+	return node
+}
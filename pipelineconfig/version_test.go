@@ -0,0 +1,100 @@
+package pipelineconfig_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/pipelineconfig"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	var (
+		config string
+	)
+
+	BeforeEach(func() {
+		config = `---
+jobs:
+- name: some-job
+  plan:
+  - get: some-resource
+    trigger: true
+resources:
+- name: some-resource
+  type: git
+`
+	})
+
+	It("returns the sha256 of the canonical config, prefixed by the algorithm", func() {
+		version, err := pipelineconfig.Version([]byte(config))
+		Expect(err).NotTo(HaveOccurred())
+
+		canonical := `{"jobs":[{"name":"some-job","plan":[{"get":"some-resource","trigger":true}]}],"resources":[{"name":"some-resource","type":"git"}]}`
+		Expect(version).To(Equal(fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(canonical)))))
+	})
+
+	It("is not affected by key ordering, whitespace or quoting", func() {
+		reformatted := `resources:
+  - type: "git"
+    name: 'some-resource'
+jobs:
+  - plan: [{trigger: true, get: some-resource}]
+    name: some-job
+`
+
+		version, err := pipelineconfig.Version([]byte(config))
+		Expect(err).NotTo(HaveOccurred())
+
+		reformattedVersion, err := pipelineconfig.Version([]byte(reformatted))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reformattedVersion).To(Equal(version))
+	})
+
+	It("changes when the content changes", func() {
+		version, err := pipelineconfig.Version([]byte(config))
+		Expect(err).NotTo(HaveOccurred())
+
+		otherVersion, err := pipelineconfig.Version([]byte(config + "groups: []\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(otherVersion).NotTo(Equal(version))
+	})
+
+	Context("when the config is not valid YAML", func() {
+		It("returns an error", func() {
+			_, err := pipelineconfig.Version([]byte("{{{"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("JSON", func() {
+	It("returns the config as indented JSON with sorted keys", func() {
+		config := `---
+resources:
+- type: git
+  name: some-resource
+`
+
+		output, err := pipelineconfig.JSON([]byte(config))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(output)).To(Equal(`{
+  "resources": [
+    {
+      "name": "some-resource",
+      "type": "git"
+    }
+  ]
+}
+`))
+	})
+
+	It("returns an error if the config cannot be parsed", func() {
+		_, err := pipelineconfig.JSON([]byte("{{"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,37 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
+)
+
+func validateAllTeams(source concourse.Source) error {
+	_, err := filter.New(source.IncludeTeams, source.ExcludeTeams)
+	if err != nil {
+		return fmt.Errorf("team filters are invalid: %v", err)
+	}
+
+	if source.AllTeams == "" {
+		return nil
+	}
+
+	allTeams, err := strconv.ParseBool(source.AllTeams)
+	if err != nil {
+		return fmt.Errorf("%s must be a boolean-parseable string if provided in source", "all_teams")
+	}
+
+	if !allTeams {
+		return nil
+	}
+
+	for _, team := range source.Teams {
+		if team.Name == concourse.MainTeamName {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("team '%s' must be provided in source if %s is set", concourse.MainTeamName, "all_teams")
+}
@@ -7,9 +7,45 @@ import (
 )
 
 func ValidateCheck(input concourse.CheckRequest) error {
-	if input.Source.Target == "" {
+	err := validateTargets(input.Source)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range input.Source.TargetSources() {
+		err := validateCheckTarget(target.Source)
+		if err != nil {
+			return targetError(target.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateCheckTarget(source concourse.Source) error {
+	if source.Target == "" {
 		return fmt.Errorf("%s must be provided in source", "target")
 	}
 
-	return ValidateTeams(input.Source.Teams)
+	err := validateClient(source.Client)
+	if err != nil {
+		return err
+	}
+
+	err = validateConcurrency(source.Concurrency)
+	if err != nil {
+		return err
+	}
+
+	err = validateTLS(source)
+	if err != nil {
+		return err
+	}
+
+	err = validateAllTeams(source)
+	if err != nil {
+		return err
+	}
+
+	return ValidateTeams(source.Teams)
 }
@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+func validateClient(client string) error {
+	switch client {
+	case "", concourse.ClientFly, concourse.ClientAPI:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%s must be one of '%s' or '%s' if provided in source",
+		"client",
+		concourse.ClientFly,
+		concourse.ClientAPI,
+	)
+}
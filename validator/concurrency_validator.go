@@ -0,0 +1,13 @@
+package validator
+
+import (
+	"fmt"
+)
+
+func validateConcurrency(concurrency int) error {
+	if concurrency < 0 {
+		return fmt.Errorf("%s must not be negative if provided in source", "concurrency")
+	}
+
+	return nil
+}
@@ -7,9 +7,82 @@ import (
 )
 
 func ValidateIn(input concourse.InRequest) error {
-	if input.Source.Target == "" {
+	err := validateTargets(input.Source)
+	if err != nil {
+		return err
+	}
+
+	switch input.Params.OnVersionMismatch {
+	case "", concourse.VersionMismatchWarn, concourse.VersionMismatchFail:
+	default:
+		return fmt.Errorf(
+			"%s must be one of '%s' or '%s' if provided in params",
+			"on_version_mismatch",
+			concourse.VersionMismatchWarn,
+			concourse.VersionMismatchFail,
+		)
+	}
+
+	switch input.Params.Layout {
+	case "", concourse.LayoutFlat, concourse.LayoutTeam:
+	default:
+		return fmt.Errorf(
+			"%s must be one of '%s' or '%s' if provided in params",
+			"layout",
+			concourse.LayoutFlat,
+			concourse.LayoutTeam,
+		)
+	}
+
+	switch input.Params.Format {
+	case "", concourse.FormatYAML, concourse.FormatJSON:
+	default:
+		return fmt.Errorf(
+			"%s must be one of '%s' or '%s' if provided in params",
+			"format",
+			concourse.FormatYAML,
+			concourse.FormatJSON,
+		)
+	}
+
+	if input.Params.ConfigDir != "" && input.Params.PipelinesFile == "" {
+		return fmt.Errorf("%s requires %s in params", "config_dir", "pipelines_file")
+	}
+
+	for _, target := range input.Source.TargetSources() {
+		err := validateInTarget(target.Source)
+		if err != nil {
+			return targetError(target.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateInTarget(source concourse.Source) error {
+	if source.Target == "" {
 		return fmt.Errorf("%s must be provided in source", "target")
 	}
 
-	return ValidateTeams(input.Source.Teams)
+	err := validateClient(source.Client)
+	if err != nil {
+		return err
+	}
+
+	err = validateConcurrency(source.Concurrency)
+	if err != nil {
+		return err
+	}
+
+	err = validateTLS(source)
+	if err != nil {
+		return err
+	}
+
+	err = validateAllTeams(source)
+	if err != nil {
+		return err
+	}
+
+	return ValidateTeams(source.Teams)
 }
@@ -4,9 +4,52 @@ import (
 	"fmt"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
 )
 
 func ValidateOut(input concourse.OutRequest) error {
+	err := validateTargets(input.Source)
+	if err != nil {
+		return err
+	}
+
+	if input.Params.Atomic && !input.Params.FailsFast() {
+		return fmt.Errorf("%s must not be false along with %s", "fail_fast", "atomic")
+	}
+
+	if len(input.Source.Targets) > 0 {
+		var targetNames []string
+		for _, t := range input.Source.Targets {
+			targetNames = append(targetNames, t.Name)
+		}
+
+		for i, p := range input.Params.Pipelines {
+			for _, targetName := range p.Targets {
+				if !stringContains(targetNames, targetName) {
+					return fmt.Errorf("target name '%s' not found in source target names for pipeline[%d]: %v", targetName, i, targetNames)
+				}
+			}
+		}
+	}
+
+	for _, target := range input.Source.TargetSources() {
+		targetInput := input.ForTarget(target)
+
+		// Targets on which no pipelines are to be set are skipped
+		if len(targetInput.Params.Pipelines) == 0 && len(input.Params.Pipelines) > 0 {
+			continue
+		}
+
+		err := validateOutTarget(targetInput)
+		if err != nil {
+			return targetError(target.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateOutTarget(input concourse.OutRequest) error {
 	err := ValidateTeams(input.Source.Teams)
 	if err != nil {
 		return err
@@ -21,6 +64,21 @@ func ValidateOut(input concourse.OutRequest) error {
 		return fmt.Errorf("%s must be provided in source", "target")
 	}
 
+	err = validateClient(input.Source.Client)
+	if err != nil {
+		return err
+	}
+
+	err = validateConcurrency(input.Source.Concurrency)
+	if err != nil {
+		return err
+	}
+
+	err = validateTLS(input.Source)
+	if err != nil {
+		return err
+	}
+
 	var pipelinesFilePresent bool
 	var pipelinesPresent bool
 
@@ -48,6 +106,28 @@ func ValidateOut(input concourse.OutRequest) error {
 		)
 	}
 
+	_, err = filter.New(input.Params.ProtectedPipelines, nil)
+	if err != nil {
+		return fmt.Errorf("%s are invalid: %v", "protected_pipelines", err)
+	}
+
+	err = validateTeamConfigs(input.Params.Teams, sourceTeamNames)
+	if err != nil {
+		return err
+	}
+
+	for _, teamName := range input.Params.Order.TeamNames() {
+		if !stringContains(sourceTeamNames, teamName) {
+			return fmt.Errorf("team name '%s' in %s not found in source team names: %v", teamName, "order", sourceTeamNames)
+		}
+
+		for j, name := range input.Params.Order.Teams[teamName] {
+			if name == "" {
+				return fmt.Errorf("%s must be non-empty for order[%s][%d]", "pipeline name", teamName, j)
+			}
+		}
+	}
+
 	for i, p := range input.Params.Pipelines {
 		if p.Name == "" {
 			return fmt.Errorf("%s must be provided for pipeline[%d]", "name", i)
@@ -65,6 +145,11 @@ func ValidateOut(input concourse.OutRequest) error {
 			return fmt.Errorf("team name '%s' not found in source team names: %v", p.TeamName, sourceTeamNames)
 		}
 
+		err = validatePipelineState(p, i)
+		if err != nil {
+			return err
+		}
+
 		// vars files can be nil as it is optional.
 		if p.VarsFiles != nil {
 			// However, if it is provided it must be non-empty
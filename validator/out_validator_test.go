@@ -87,6 +87,110 @@ var _ = Describe("ValidateOut", func() {
 		})
 	})
 
+	Context("when the api client is selected", func() {
+		BeforeEach(func() {
+			outRequest.Source.Client = "api"
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+		})
+	})
+
+	Context("when an unknown client is provided", func() {
+		BeforeEach(func() {
+			outRequest.Source.Client = "some-client"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*client.*one of.*fly.*api"))
+		})
+	})
+
+	Context("when concurrency is negative", func() {
+		BeforeEach(func() {
+			outRequest.Source.Concurrency = -1
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*concurrency.*negative"))
+		})
+	})
+
+	Context("when ca_cert is not PEM encoded", func() {
+		BeforeEach(func() {
+			outRequest.Source.CACert = "some-ca-cert"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*ca_cert.*PEM"))
+		})
+	})
+
+	Context("when client_cert is provided without client_key", func() {
+		BeforeEach(func() {
+			outRequest.Source.ClientCert = "some-client-cert"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*client_cert.*client_key.*together"))
+		})
+	})
+
+	Context("when client_cert and client_key are not a valid key pair", func() {
+		BeforeEach(func() {
+			outRequest.Source.ClientCert = "some-client-cert"
+			outRequest.Source.ClientKey = "some-client-key"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*client_cert.*client_key.*PEM"))
+		})
+	})
+
+	Context("when atomic is provided along with fail_fast false", func() {
+		BeforeEach(func() {
+			failFast := false
+			outRequest.Params.Atomic = true
+			outRequest.Params.FailFast = &failFast
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*fail_fast.*atomic"))
+		})
+	})
+
+	Context("when protected pipelines are invalid", func() {
+		BeforeEach(func() {
+			outRequest.Params.ProtectedPipelines = []string{"["}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*protected_pipelines.*invalid"))
+		})
+	})
+
 	Context("when pipelines param is nil", func() {
 		BeforeEach(func() {
 			outRequest.Params.Pipelines = nil
@@ -152,6 +256,275 @@ var _ = Describe("ValidateOut", func() {
 		})
 	})
 
+	Context("when a pipeline state is provided", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].State = "archived"
+			outRequest.Params.Pipelines[0].Visibility = "hidden"
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+		})
+	})
+
+	Context("when an unknown pipeline state is provided", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].State = "some-state"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*state.*one of.*paused.*unpaused.*archived"))
+		})
+	})
+
+	Context("when unpaused conflicts with the pipeline state", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].Unpaused = true
+			outRequest.Params.Pipelines[0].State = "paused"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*unpaused.*conflicts.*state.*paused"))
+		})
+	})
+
+	Context("when an unknown pipeline visibility is provided", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].Visibility = "some-visibility"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*visibility.*one of.*exposed.*hidden"))
+		})
+	})
+
+	Context("when exposed conflicts with the pipeline visibility", func() {
+		BeforeEach(func() {
+			outRequest.Params.Pipelines[0].Exposed = true
+			outRequest.Params.Pipelines[0].Visibility = "hidden"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*exposed.*conflicts.*visibility.*hidden"))
+		})
+	})
+
+	Context("when order names a team not provided in source", func() {
+		BeforeEach(func() {
+			outRequest.Params.Order = concourse.PipelineOrder{
+				Teams: map[string][]string{"not-supplied": {"p1"}},
+			}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*not-supplied.*order.*not found.*source.*"))
+		})
+	})
+
+	Context("when order contains an empty pipeline name", func() {
+		BeforeEach(func() {
+			outRequest.Params.Order = concourse.PipelineOrder{
+				Teams: map[string][]string{"some team": {"p1", ""}},
+			}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*pipeline name.*non-empty.*order"))
+		})
+	})
+
+	Context("when teams are provided", func() {
+		BeforeEach(func() {
+			outRequest.Source.Teams = append(outRequest.Source.Teams, concourse.Team{
+				Name:     "main",
+				Username: "main username",
+				Password: "main password",
+			})
+
+			outRequest.Params.Teams = []concourse.TeamConfig{
+				{
+					Name: "new team",
+					Roles: map[string]concourse.TeamRole{
+						"owner":  {Users: []string{"local:admin"}},
+						"viewer": {Groups: []string{"github:my-org:my-team"}},
+					},
+				},
+			}
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+		})
+
+		Context("when main team credentials are not provided in source", func() {
+			BeforeEach(func() {
+				outRequest.Source.Teams = outRequest.Source.Teams[:2]
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*main.*provided.*source.*teams"))
+			})
+		})
+
+		Context("when a team is provided twice", func() {
+			BeforeEach(func() {
+				outRequest.Params.Teams = append(outRequest.Params.Teams, outRequest.Params.Teams[0])
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*new team.*only.*once"))
+			})
+		})
+
+		Context("when a team has no roles", func() {
+			BeforeEach(func() {
+				outRequest.Params.Teams[0].Roles = nil
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*roles.*provided.*teams\\[0\\]"))
+			})
+		})
+
+		Context("when an unknown role is provided", func() {
+			BeforeEach(func() {
+				outRequest.Params.Teams[0].Roles["some-role"] = concourse.TeamRole{}
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*some-role.*one of.*owner.*member.*pipeline-operator.*viewer"))
+			})
+		})
+
+		Context("when a user has no connector", func() {
+			BeforeEach(func() {
+				outRequest.Params.Teams[0].Roles["owner"] = concourse.TeamRole{Users: []string{"admin"}}
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*admin.*connector.*name.*teams\\[0\\].roles.owner"))
+			})
+		})
+	})
+
+	Context("when targets are provided", func() {
+		BeforeEach(func() {
+			outRequest.Source = concourse.Source{
+				Targets: []concourse.Target{
+					{
+						Name:   "prod",
+						Target: "prod target",
+						Teams:  outRequest.Source.Teams,
+					},
+					{
+						Name:   "dr",
+						Target: "dr target",
+						Teams:  outRequest.Source.Teams[:1],
+					},
+				},
+			}
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+		})
+
+		Context("when target is also provided in source", func() {
+			BeforeEach(func() {
+				outRequest.Source.Target = "some target"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*target.*not.*provided.*along with targets"))
+			})
+		})
+
+		Context("when a target name is provided twice", func() {
+			BeforeEach(func() {
+				outRequest.Source.Targets[1].Name = "prod"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*name.*unique.*prod"))
+			})
+		})
+
+		Context("when a pipeline names an unknown target", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].Targets = []string{"staging"}
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*staging.*not found.*target names.*pipeline\\[0\\]"))
+			})
+		})
+
+		Context("when a pipeline's team is not provided for one of its targets", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].TeamName = "other team"
+			})
+
+			It("returns an error naming the target", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp("^target 'dr': team name 'other team' not found"))
+			})
+
+			Context("when the pipeline is not set on that target", func() {
+				BeforeEach(func() {
+					outRequest.Params.Pipelines[0].Targets = []string{"prod"}
+				})
+
+				It("returns without error", func() {
+					Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+				})
+			})
+		})
+	})
+
 	Context("when team name is not provided in source", func() {
 		BeforeEach(func() {
 			outRequest.Params.Pipelines[0].TeamName = "not-supplied"
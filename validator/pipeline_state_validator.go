@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+func validatePipelineState(p concourse.Pipeline, i int) error {
+	switch p.State {
+	case "", concourse.PipelineStatePaused, concourse.PipelineStateUnpaused, concourse.PipelineStateArchived:
+	default:
+		return fmt.Errorf(
+			"%s must be one of '%s', '%s' or '%s' if provided for pipeline[%d]",
+			"state",
+			concourse.PipelineStatePaused,
+			concourse.PipelineStateUnpaused,
+			concourse.PipelineStateArchived,
+			i,
+		)
+	}
+
+	if p.Unpaused && p.State != "" && p.State != concourse.PipelineStateUnpaused {
+		return fmt.Errorf("%s conflicts with %s '%s' for pipeline[%d]", "unpaused", "state", p.State, i)
+	}
+
+	switch p.Visibility {
+	case "", concourse.PipelineVisibilityExposed, concourse.PipelineVisibilityHidden:
+	default:
+		return fmt.Errorf(
+			"%s must be one of '%s' or '%s' if provided for pipeline[%d]",
+			"visibility",
+			concourse.PipelineVisibilityExposed,
+			concourse.PipelineVisibilityHidden,
+			i,
+		)
+	}
+
+	if p.Exposed && p.Visibility != "" && p.Visibility != concourse.PipelineVisibilityExposed {
+		return fmt.Errorf("%s conflicts with %s '%s' for pipeline[%d]", "exposed", "visibility", p.Visibility, i)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+func validateTargets(source concourse.Source) error {
+	if len(source.Targets) == 0 {
+		return nil
+	}
+
+	if source.Target != "" {
+		return fmt.Errorf("%s must not be provided in source along with %s", "target", "targets")
+	}
+
+	if len(source.Teams) > 0 {
+		return fmt.Errorf("%s must not be provided in source along with %s", "teams", "targets")
+	}
+
+	var names []string
+	for i, t := range source.Targets {
+		if t.Name == "" {
+			return fmt.Errorf("%s must be provided for targets[%d]", "name", i)
+		}
+
+		if stringContains(names, t.Name) {
+			return fmt.Errorf("%s must be unique for target: %s", "name", t.Name)
+		}
+		names = append(names, t.Name)
+	}
+
+	return nil
+}
+
+// targetError qualifies an error with the name of the target it is for, unless
+// the target is unnamed.
+func targetError(targetName string, err error) error {
+	if err == nil || targetName == "" {
+		return err
+	}
+
+	return fmt.Errorf("target '%s': %v", targetName, err)
+}
@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+func validateTeamConfigs(teams []concourse.TeamConfig, sourceTeamNames []string) error {
+	if len(teams) == 0 {
+		return nil
+	}
+
+	if !stringContains(sourceTeamNames, concourse.MainTeamName) {
+		return fmt.Errorf("team '%s' must be provided in source to set %s", concourse.MainTeamName, "teams")
+	}
+
+	var names []string
+	for i, team := range teams {
+		if team.Name == "" {
+			return fmt.Errorf("%s must be provided for teams[%d]", "name", i)
+		}
+
+		if stringContains(names, team.Name) {
+			return fmt.Errorf("team '%s' must only be provided once in %s", team.Name, "teams")
+		}
+		names = append(names, team.Name)
+
+		if len(team.Roles) == 0 {
+			return fmt.Errorf("%s must be provided for teams[%d]", "roles", i)
+		}
+
+		for roleName, role := range team.Roles {
+			switch roleName {
+			case concourse.TeamRoleOwner, concourse.TeamRoleMember, concourse.TeamRolePipelineOperator, concourse.TeamRoleViewer:
+			default:
+				return fmt.Errorf(
+					"role '%s' must be one of '%s', '%s', '%s' or '%s' for teams[%d]",
+					roleName,
+					concourse.TeamRoleOwner,
+					concourse.TeamRoleMember,
+					concourse.TeamRolePipelineOperator,
+					concourse.TeamRoleViewer,
+					i,
+				)
+			}
+
+			for _, member := range append(append([]string{}, role.Users...), role.Groups...) {
+				parts := strings.SplitN(member, ":", 2)
+				if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+					return fmt.Errorf(
+						"'%s' must be of the form <connector>:<name> for teams[%d].roles.%s",
+						member,
+						i,
+						roleName,
+					)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/filter"
 )
 
 func ValidateTeams(teams []concourse.Team) error {
@@ -11,11 +12,18 @@ func ValidateTeams(teams []concourse.Team) error {
 		return fmt.Errorf("%s must be provided in source", "teams")
 	}
 
+	teamNames := []string{}
+
 	for i, team := range teams {
 		if team.Name == "" {
 			return fmt.Errorf("%s must be provided for team: %d", "name", i)
 		}
 
+		if stringContains(teamNames, team.Name) {
+			return fmt.Errorf("%s must be unique for team: %s", "name", team.Name)
+		}
+		teamNames = append(teamNames, team.Name)
+
 		if team.Username == "" && team.Password != "" {
 			return fmt.Errorf("%s must be provided for team: %s", "username", team.Name)
 		}
@@ -23,6 +31,47 @@ func ValidateTeams(teams []concourse.Team) error {
 		if team.Password == "" && team.Username != "" {
 			return fmt.Errorf("%s must be provided for team: %s", "password", team.Name)
 		}
+
+		clientCredentialsProvided := team.ClientID != "" || team.ClientSecret != "" || team.TokenURL != ""
+		if clientCredentialsProvided {
+			if team.ClientID == "" {
+				return fmt.Errorf("%s must be provided for team: %s", "client_id", team.Name)
+			}
+
+			if team.ClientSecret == "" {
+				return fmt.Errorf("%s must be provided for team: %s", "client_secret", team.Name)
+			}
+
+			if team.TokenURL == "" {
+				return fmt.Errorf("%s must be provided for team: %s", "token_url", team.Name)
+			}
+		}
+
+		authMethods := 0
+		for _, provided := range []bool{
+			team.Username != "",
+			team.Token != "",
+			clientCredentialsProvided,
+		} {
+			if provided {
+				authMethods++
+			}
+		}
+
+		if authMethods > 1 {
+			return fmt.Errorf(
+				"only one of %s, %s or %s may be provided for team: %s",
+				"username/password",
+				"token",
+				"client_id/client_secret/token_url",
+				team.Name,
+			)
+		}
+
+		_, err := filter.New(team.IncludePipelines, team.ExcludePipelines)
+		if err != nil {
+			return fmt.Errorf("pipeline filters are invalid for team: %s: %v", team.Name, err)
+		}
 	}
 
 	return nil
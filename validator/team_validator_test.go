@@ -80,6 +80,111 @@ var _ = Describe("ValidateTeams", func() {
 		})
 	})
 
+	Context("when a token is provided instead of a username and password", func() {
+		BeforeEach(func() {
+			teams[0].Username = ""
+			teams[0].Password = ""
+			teams[0].Token = "some-token"
+		})
+
+		It("does not throw an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when client credentials are provided instead of a username and password", func() {
+		BeforeEach(func() {
+			teams[0].Username = ""
+			teams[0].Password = ""
+			teams[0].ClientID = "some-client-id"
+			teams[0].ClientSecret = "some-client-secret"
+			teams[0].TokenURL = "https://some-idp/token"
+		})
+
+		It("does not throw an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when no client secret is provided", func() {
+			BeforeEach(func() {
+				teams[0].ClientSecret = ""
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*client_secret.*provided.*team.*%s", "some team"))
+			})
+		})
+
+		Context("when no token url is provided", func() {
+			BeforeEach(func() {
+				teams[0].TokenURL = ""
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*token_url.*provided.*team.*%s", "some team"))
+			})
+		})
+	})
+
+	Context("when both a username and password and a token are provided", func() {
+		BeforeEach(func() {
+			teams[0].Token = "some-token"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*only one of.*team.*%s", "some team"))
+		})
+	})
+
+	Context("when valid pipeline filters are provided", func() {
+		BeforeEach(func() {
+			teams[0].IncludePipelines = []string{"deploy-*"}
+			teams[0].ExcludePipelines = []string{"/-prod$/"}
+		})
+
+		It("does not throw an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("when an invalid pipeline filter is provided", func() {
+		BeforeEach(func() {
+			teams[0].ExcludePipelines = []string{"/(/"}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*filters.*invalid.*team.*%s", "some team"))
+		})
+	})
+
+	Context("when team names are not unique", func() {
+		BeforeEach(func() {
+			teams = append(teams, teams[0])
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*name.*unique.*team.*%s", "some team"))
+		})
+	})
+
 	Context("when there are no teams", func() {
 		It("returns an error", func() {
 			err := validator.ValidateTeams([]concourse.Team{})
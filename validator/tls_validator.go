@@ -0,0 +1,30 @@
+package validator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+func validateTLS(source concourse.Source) error {
+	if source.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(source.CACert)) {
+		return fmt.Errorf("%s must contain a PEM encoded certificate if provided in source", "ca_cert")
+	}
+
+	if source.ClientCert == "" && source.ClientKey == "" {
+		return nil
+	}
+
+	if source.ClientCert == "" || source.ClientKey == "" {
+		return fmt.Errorf("%s and %s must be provided together in source", "client_cert", "client_key")
+	}
+
+	_, err := tls.X509KeyPair([]byte(source.ClientCert), []byte(source.ClientKey))
+	if err != nil {
+		return fmt.Errorf("%s and %s must be a PEM encoded certificate and its key: %s", "client_cert", "client_key", err)
+	}
+
+	return nil
+}